@@ -0,0 +1,272 @@
+// rankeval 用历史抓取数据回放两组配置，对比排序结果前 N 条的差异
+//
+// 运行：go run ./cmd/rankeval -cachedb data/data_cache.db -set keyword_weight=0.5,strategy=rrf
+//
+// bbolt 不允许与正在运行的服务同时打开数据库，服务运行时请先复制 data_cache.db
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/datacache"
+	"github.com/gotoailab/trendhub/internal/feedback"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+	"github.com/gotoailab/trendhub/internal/rank"
+	"github.com/gotoailab/trendhub/internal/rankeval"
+	"github.com/gotoailab/trendhub/internal/watchlist"
+	"gopkg.in/yaml.v3"
+)
+
+// DayResult 一天的对比结果
+type DayResult struct {
+	Date       string               `json:"date"`
+	CrawledAt  time.Time            `json:"crawled_at"`
+	BaseCount  int                  `json:"base_count"`
+	AltCount   int                  `json:"alt_count"`
+	Comparison *rankeval.Comparison `json:"comparison"`
+}
+
+func main() {
+	cacheDBPath := flag.String("cachedb", "data/data_cache.db", "数据缓存数据库（含 crawl_history）")
+	configPath := flag.String("config", "config/config.yaml", "基准配置文件")
+	keywordPath := flag.String("keywords", "config/frequency_words.txt", "基准关键词文件")
+	altConfigPath := flag.String("alt-config", "", "候选配置文件，默认与基准相同")
+	altKeywordPath := flag.String("alt-keywords", "", "候选关键词文件，默认与基准相同")
+	overrides := flag.String("set", "", "覆盖候选配置中 weight 下的参数，如 keyword_weight=0.5,strategy=rrf,diversity.enabled=true")
+	pushDBPath := flag.String("pushdb", "", "推送记录数据库，feedback 策略从中读取反馈")
+	date := flag.String("date", "", "只回放指定日期（YYYY-MM-DD）")
+	days := flag.Int("days", 7, "回放最近 N 天")
+	top := flag.Int("top", 20, "对比前 N 条")
+	jsonOutput := flag.Bool("json", false, "以 JSON 输出")
+	flag.Parse()
+
+	if *altConfigPath == "" {
+		*altConfigPath = *configPath
+	}
+	if *altKeywordPath == "" {
+		*altKeywordPath = *keywordPath
+	}
+
+	baseCfg, err := config.LoadConfig(*configPath, *keywordPath)
+	if err != nil {
+		log.Fatalf("加载基准配置失败: %v", err)
+	}
+	altCfg, err := config.LoadConfig(*altConfigPath, *altKeywordPath)
+	if err != nil {
+		log.Fatalf("加载候选配置失败: %v", err)
+	}
+	if err := applyOverrides(&altCfg.Config.Weight, *overrides); err != nil {
+		log.Fatalf("-set 参数有误: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	lists := watchlist.NewStore()
+	base, err := rankeval.NewVariant(ctx, "基准", baseCfg, lists)
+	if err != nil {
+		log.Fatalf("基准配置: %v", err)
+	}
+	alt, err := rankeval.NewVariant(ctx, "候选", altCfg, lists)
+	if err != nil {
+		log.Fatalf("候选配置: %v", err)
+	}
+	if *pushDBPath != "" {
+		if err := loadBoosts(*pushDBPath, base, alt); err != nil {
+			log.Fatalf("读取反馈失败: %v", err)
+		}
+	}
+
+	cache, err := datacache.OpenReadOnly(*cacheDBPath)
+	if err != nil {
+		log.Fatalf("打开数据缓存失败（服务运行时请先复制数据库文件）: %v", err)
+	}
+	defer cache.Close()
+
+	dates, err := selectDates(cache, *date, *days)
+	if err != nil {
+		log.Fatalf("读取抓取历史失败: %v", err)
+	}
+	if len(dates) == 0 {
+		log.Fatalf("没有可回放的抓取历史")
+	}
+
+	var results []*DayResult
+	for _, d := range dates {
+		result, err := replay(cache, d, base, alt, *top)
+		if err != nil {
+			log.Printf("跳过 %s: %v", d, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		log.Fatalf("没有成功回放的日期")
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+		return
+	}
+	printReport(results, describe(*configPath, *keywordPath, baseCfg, ""), describe(*altConfigPath, *altKeywordPath, altCfg, *overrides), *top)
+}
+
+// applyOverrides 将 key=value 形式的参数写入 weight 配置，嵌套字段用点号分隔
+func applyOverrides(w *config.WeightConfig, overrides string) error {
+	for _, pair := range strings.Split(overrides, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q 缺少 =", pair)
+		}
+
+		// 按点号拆成嵌套的 YAML，只覆盖指定的字段
+		var sb strings.Builder
+		parts := strings.Split(strings.TrimSpace(key), ".")
+		for i, part := range parts {
+			sb.WriteString(strings.Repeat("  ", i))
+			sb.WriteString(part)
+			sb.WriteString(":")
+			if i < len(parts)-1 {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(" ")
+		sb.WriteString(strings.TrimSpace(value))
+		if err := yaml.Unmarshal([]byte(sb.String()), w); err != nil {
+			return fmt.Errorf("%q: %w", pair, err)
+		}
+	}
+	return nil
+}
+
+// loadBoosts 为使用 feedback 策略的配置加载反馈系数
+func loadBoosts(path string, variants ...*rankeval.Variant) error {
+	db, err := pushdb.OpenReadOnly(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, v := range variants {
+		if !strings.EqualFold(strings.TrimSpace(v.Config.Config.Weight.Strategy), rank.StrategyFeedback) {
+			continue
+		}
+		cfg := v.Config.Config.Feedback
+		records, err := db.GetFeedbackSince(time.Now().AddDate(0, 0, -feedback.Days(cfg)))
+		if err != nil {
+			return err
+		}
+		v.Boosts = feedback.Learn(records, cfg).Boosts()
+	}
+	return nil
+}
+
+// selectDates 返回要回放的日期：指定日期，或最近 days 天
+func selectDates(cache *datacache.DataCache, date string, days int) ([]string, error) {
+	if date != "" {
+		return []string{date}, nil
+	}
+	dates, err := cache.CrawlHistoryDates()
+	if err != nil {
+		return nil, err
+	}
+	if days > 0 && len(dates) > days {
+		dates = dates[len(dates)-days:]
+	}
+	return dates, nil
+}
+
+// replay 用两组配置分别排序一天的抓取数据并对比
+func replay(cache *datacache.DataCache, date string, base, alt *rankeval.Variant, top int) (*DayResult, error) {
+	// 过滤和排序会修改新闻，两组配置各读取一份独立的数据
+	baseHistory, err := cache.GetCrawlHistory(date)
+	if err != nil {
+		return nil, err
+	}
+	altHistory, err := cache.GetCrawlHistory(date)
+	if err != nil {
+		return nil, err
+	}
+
+	baseItems, err := base.Rank(baseHistory.Data, baseHistory.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("基准配置: %w", err)
+	}
+	altItems, err := alt.Rank(altHistory.Data, altHistory.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("候选配置: %w", err)
+	}
+
+	return &DayResult{
+		Date:       date,
+		CrawledAt:  baseHistory.Timestamp,
+		BaseCount:  len(baseItems),
+		AltCount:   len(altItems),
+		Comparison: rankeval.Compare(baseItems, altItems, top),
+	}, nil
+}
+
+func describe(configPath, keywordPath string, cfg *config.GlobalConfig, overrides string) string {
+	strategy := cfg.Config.Weight.Strategy
+	if strategy == "" {
+		strategy = rank.StrategyWeighted
+	}
+	desc := fmt.Sprintf("%s + %s（策略 %s）", configPath, keywordPath, strategy)
+	if overrides != "" {
+		desc += "，覆盖 " + overrides
+	}
+	return desc
+}
+
+func printReport(results []*DayResult, baseDesc, altDesc string, top int) {
+	fmt.Printf("基准: %s\n", baseDesc)
+	fmt.Printf("候选: %s\n", altDesc)
+	fmt.Printf("对比前 %d 条\n", top)
+
+	var overlap, rho float64
+	var surfaced, dropped int
+	for _, r := range results {
+		c := r.Comparison
+		fmt.Printf("\n%s  抓取于 %s  基准 %d 条 / 候选 %d 条  重合 %.1f%%  Spearman %.3f\n",
+			r.Date, r.CrawledAt.Format("15:04"), r.BaseCount, r.AltCount, c.Overlap*100, c.Spearman)
+		for _, m := range c.Surfaced {
+			fmt.Printf("  + 新进入 #%-3d [%s] %s（基准%s）\n", m.To, m.SourceID, m.Title, position(m.From))
+		}
+		for _, m := range c.Dropped {
+			fmt.Printf("  - 跌出   #%-3d [%s] %s（候选%s）\n", m.From, m.SourceID, m.Title, position(m.To))
+		}
+		for _, m := range c.Moved {
+			fmt.Printf("  ~ 名次   #%d → #%d [%s] %s\n", m.From, m.To, m.SourceID, m.Title)
+		}
+
+		overlap += c.Overlap
+		rho += c.Spearman
+		surfaced += len(c.Surfaced)
+		dropped += len(c.Dropped)
+	}
+
+	n := float64(len(results))
+	fmt.Printf("\n汇总 %d 天: 平均重合 %.1f%%  平均 Spearman %.3f  新进入 %d 条  跌出 %d 条\n",
+		len(results), overlap/n*100, rho/n, surfaced, dropped)
+}
+
+func position(p int) string {
+	if p == 0 {
+		return "未通过关键词过滤"
+	}
+	return fmt.Sprintf(" #%d", p)
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ import (
 type AppConfig struct {
 	VersionCheckURL   string `yaml:"version_check_url" json:"version_check_url"`
 	ShowVersionUpdate bool   `yaml:"show_version_update" json:"show_version_update"`
+	PublicURL         string `yaml:"public_url" json:"public_url"` // Web 界面的外部访问地址，用于生成推送中的反馈链接
 }
 
 // CrawlerConfig 爬虫配置
@@ -63,6 +65,32 @@ type WebhooksConfig struct {
 	BarkServerURL    string `yaml:"bark_server_url" json:"bark_server_url"`
 	BarkDeviceKey    string `yaml:"bark_device_key" json:"bark_device_key"`
 	WPSWebhookURL    string `yaml:"wps_webhook_url" json:"wps_webhook_url"`
+
+	NtfyPriority int    `yaml:"ntfy_priority" json:"ntfy_priority"`   // ntfy 消息优先级 1-5，0 表示服务器默认
+	NtfyTags     string `yaml:"ntfy_tags" json:"ntfy_tags"`           // ntfy 标签，多个用逗号分隔
+	NtfyClickURL string `yaml:"ntfy_click_url" json:"ntfy_click_url"` // 点击 ntfy 通知打开的地址，默认为 app.public_url
+	NtfyMarkdown bool   `yaml:"ntfy_markdown" json:"ntfy_markdown"`   // ntfy 正文按 Markdown 渲染
+
+	WeworkMsgType string `yaml:"wework_msg_type" json:"wework_msg_type"` // 企业微信消息类型：markdown（默认）或 news（图文卡片）
+
+	SlackWebhookURL   string `yaml:"slack_webhook_url" json:"slack_webhook_url"`     // Slack Incoming Webhook 地址
+	DiscordWebhookURL string `yaml:"discord_webhook_url" json:"discord_webhook_url"` // Discord 频道 Webhook 地址
+	TeamsWebhookURL   string `yaml:"teams_webhook_url" json:"teams_webhook_url"`     // Microsoft Teams Incoming Webhook 或 Workflows 地址
+
+	FeishuMsgType string `yaml:"feishu_msg_type" json:"feishu_msg_type"` // 飞书消息类型：text（默认）或 interactive（消息卡片）
+	FeishuSecret  string `yaml:"feishu_secret" json:"feishu_secret"`     // 飞书机器人签名校验密钥，未开启签名校验时留空
+
+	DingtalkMsgType    string `yaml:"dingtalk_msg_type" json:"dingtalk_msg_type"`       // 钉钉消息类型：markdown（默认）、actionCard 或 feedCard
+	DingtalkSecret     string `yaml:"dingtalk_secret" json:"dingtalk_secret"`           // 钉钉机器人加签密钥，未开启加签时留空
+	DingtalkAtMobiles  string `yaml:"dingtalk_at_mobiles" json:"dingtalk_at_mobiles"`   // 高优先级关键词组有新闻时 @ 的手机号，多个用逗号分隔
+	DingtalkAtPriority int    `yaml:"dingtalk_at_priority" json:"dingtalk_at_priority"` // 触发 @ 的关键词组优先级（1-10），0 表示默认值8
+
+	TelegramParseMode  string `yaml:"telegram_parse_mode" json:"telegram_parse_mode"`     // Telegram 消息格式：HTML（默认）或 MarkdownV2
+	TelegramAPIBaseURL string `yaml:"telegram_api_base_url" json:"telegram_api_base_url"` // Bot API 地址，自建 Bot API 服务器时填写，默认 https://api.telegram.org
+	TelegramThreadID   int    `yaml:"telegram_thread_id" json:"telegram_thread_id"`       // 发送到超级群组的指定话题，0 表示不指定
+	TelegramButtons    bool   `yaml:"telegram_buttons" json:"telegram_buttons"`           // 在消息下方显示“打开 TrendHub”和静音关键词组按钮，需要配置 app.public_url
+
+	EmailAllowPlaintext bool `yaml:"email_allow_plaintext" json:"email_allow_plaintext"` // SMTP 服务器不支持 STARTTLS 时仍以明文发送，仅用于本机或内网的测试服务器
 }
 
 // NotificationConfig 通知配置
@@ -75,6 +103,37 @@ type NotificationConfig struct {
 	FeishuMessageSeparator string           `yaml:"feishu_message_separator" json:"feishu_message_separator"`
 	PushWindow             PushWindowConfig `yaml:"push_window" json:"push_window"`
 	Webhooks               WebhooksConfig   `yaml:"webhooks" json:"webhooks"`
+
+	CustomWebhooks []CustomWebhookConfig `yaml:"custom_webhooks" json:"custom_webhooks"` // 自定义 Webhook
+
+	Templates TemplatesConfig `yaml:"templates" json:"templates"` // 推送消息模板
+
+	Outbox OutboxConfig `yaml:"outbox" json:"outbox"` // 发送失败的重试队列
+}
+
+// OutboxConfig 推送重试队列配置
+// 发送失败的渠道按指数退避重试，重试次数用完或过期后移入失败队列，可在 Web 界面重新发送
+type OutboxConfig struct {
+	Enabled     bool `yaml:"enabled" json:"enabled"`
+	MaxAttempts int  `yaml:"max_attempts" json:"max_attempts"` // 最多发送次数，包括首次推送，默认10
+	ExpireHours int  `yaml:"expire_hours" json:"expire_hours"` // 从首次推送起超过该时长（小时）不再重试，默认24
+}
+
+// CustomWebhookConfig 自定义 Webhook 配置
+// 请求体由 Go text/template 模板生成，可对接内部系统或 Gotify、Pushover、Server酱、PushPlus 等服务
+type CustomWebhookConfig struct {
+	Name        string            `yaml:"name" json:"name"`                 // 名称，用于日志
+	URL         string            `yaml:"url" json:"url"`                   // 请求地址，同样支持模板
+	Method      string            `yaml:"method" json:"method"`             // 请求方法，默认 POST
+	ContentType string            `yaml:"content_type" json:"content_type"` // 请求体类型，默认 application/json
+	Headers     map[string]string `yaml:"headers" json:"headers"`           // 附加的请求头，如 Authorization
+	Template    string            `yaml:"template" json:"template"`         // 请求体模板，留空发送包含 title 和 text 的 JSON
+}
+
+// TemplatesConfig 推送消息模板，未配置的部分使用各渠道的默认模板
+type TemplatesConfig struct {
+	GroupBy  string                       `yaml:"group_by" json:"group_by"` // 分组方式：source（按平台，默认）或 keyword（按关键词组）
+	Channels map[string]map[string]string `yaml:"channels" json:"channels"` // 渠道名 -> 模板部分（title、header、group、item、footer）-> 模板
 }
 
 // WeightConfig 权重配置
@@ -85,6 +144,57 @@ type WeightConfig struct {
 	KeywordWeight   float64 `yaml:"keyword_weight" json:"keyword_weight"`     // 关键词匹配权重
 	PlatformWeight  float64 `yaml:"platform_weight" json:"platform_weight"`   // 平台权重影响系数
 	FreshnessWeight float64 `yaml:"freshness_weight" json:"freshness_weight"` // 时效性权重
+
+	FreshnessHalfLife float64 `yaml:"freshness_half_life" json:"freshness_half_life"` // 时效性分的半衰期（小时），默认2
+
+	RankCurve     string `yaml:"rank_curve" json:"rank_curve"`         // 排名分曲线：reciprocal（默认）、linear、log
+	RankNormalize bool   `yaml:"rank_normalize" json:"rank_normalize"` // 按各平台榜单长度把排名换算到同一尺度，使长短不同的榜单可比
+
+	ConsensusWeight     float64 `yaml:"consensus_weight" json:"consensus_weight"`         // 跨平台共识权重，0 表示不启用
+	ConsensusSimilarity float64 `yaml:"consensus_similarity" json:"consensus_similarity"` // 判断为同一事件的标题相似度阈值（0-1），默认0.6
+
+	Diversity DiversityConfig `yaml:"diversity" json:"diversity"` // 多样性重排
+
+	Strategy      string  `yaml:"strategy" json:"strategy"`               // 排序策略，默认 weighted
+	RRFK          float64 `yaml:"rrf_k" json:"rrf_k"`                     // reciprocal-rank-fusion 的平滑常数 k，默认60
+	DecayHalfLife float64 `yaml:"decay_half_life" json:"decay_half_life"` // recency-decay 的半衰期（小时），默认6
+}
+
+// DiversityConfig 多样性重排配置
+// 排序后在前 Window 条内限制单个平台、单个关键词组的占比，避免结果被同一来源或话题占满
+type DiversityConfig struct {
+	Enabled          bool    `yaml:"enabled" json:"enabled"`
+	Window           int     `yaml:"window" json:"window"`                         // 重排范围：前 N 条，默认20
+	MaxPlatformShare float64 `yaml:"max_platform_share" json:"max_platform_share"` // 单个平台的最大占比（0-1），0 表示不限制
+	MaxGroupShare    float64 `yaml:"max_group_share" json:"max_group_share"`       // 单个关键词组的最大占比（0-1），0 表示不限制
+}
+
+// URLRule 按链接域名、路径或来源平台过滤的规则
+// 规则按顺序匹配，第一条命中的规则决定保留(include)或排除(exclude)，均未命中则保留
+type URLRule struct {
+	Action     string   `yaml:"action" json:"action"`           // include 或 exclude
+	Hosts      []string `yaml:"hosts" json:"hosts"`             // 域名，同时匹配其子域名
+	Paths      []string `yaml:"paths" json:"paths"`             // 路径通配模式，如 /video/*，以 /** 结尾匹配整个前缀
+	Platforms  []string `yaml:"platforms" json:"platforms"`     // 来源平台ID
+	UnlessTags []string `yaml:"unless_tags" json:"unless_tags"` // 匹配的关键词组带有任一标签时跳过该规则
+}
+
+// SemanticConfig 语义匹配配置
+// 开启后，未命中字面关键词的标题会与关键词组的描述（[desc:...]）按向量相似度匹配
+type SemanticConfig struct {
+	Enabled   bool    `yaml:"enabled" json:"enabled"`
+	Provider  string  `yaml:"provider" json:"provider"`   // openai（兼容 OpenAI 的接口）或 ollama
+	BaseURL   string  `yaml:"base_url" json:"base_url"`   // 接口地址，留空使用服务默认地址
+	APIKey    string  `yaml:"api_key" json:"api_key"`     // 接口密钥，本地服务可留空
+	Model     string  `yaml:"model" json:"model"`         // 向量模型名称
+	Threshold float64 `yaml:"threshold" json:"threshold"` // 余弦相似度阈值，默认0.75
+	Timeout   int     `yaml:"timeout" json:"timeout"`     // 请求超时（秒），默认10
+}
+
+// FilterConfig 过滤配置
+type FilterConfig struct {
+	URLRules []URLRule      `yaml:"url_rules" json:"url_rules"`
+	Semantic SemanticConfig `yaml:"semantic" json:"semantic"`
 }
 
 // Config 总配置结构
@@ -94,16 +204,42 @@ type Config struct {
 	Report       ReportConfig       `yaml:"report" json:"report"`
 	Notification NotificationConfig `yaml:"notification" json:"notification"`
 	Weight       WeightConfig       `yaml:"weight" json:"weight"`
+	Filter       FilterConfig       `yaml:"filter" json:"filter"`
+	Feedback     FeedbackConfig     `yaml:"feedback" json:"feedback"`
 	Platforms    []model.Platform   `yaml:"platforms" json:"platforms"`
 }
 
+// FeedbackConfig 阅读反馈配置
+// 读者在 Web 界面或推送链接中标记新闻是否有用，feedback 排序策略据此调整平台和关键词组的权重
+type FeedbackConfig struct {
+	TrackLinks bool    `yaml:"track_links" json:"track_links"` // 推送中的新闻链接经过 app.public_url 跳转并记录点击
+	Strength   float64 `yaml:"strength" json:"strength"`       // 学习强度（0-1），反馈对权重的最大调整幅度，默认0.5
+	MinVotes   int     `yaml:"min_votes" json:"min_votes"`     // 平台或关键词组至少有多少条反馈才参与调整，默认5
+	Days       int     `yaml:"days" json:"days"`               // 参与学习的反馈天数，默认30
+
+	MuteHours int `yaml:"mute_hours" json:"mute_hours"` // 推送中的静音按钮暂停推送关键词组的时长（小时），默认24
+}
+
 // KeywordGroup 关键词组
 type KeywordGroup struct {
-	Required []string
-	Normal   []string
-	Filters  []string // 该组特定的过滤词（虽然原始实现是全局的，但这里保留扩展性）
-	GroupKey string
-	Priority int // 优先级：1-10，默认5，越高越重要
+	Required    []string
+	Normal      []string
+	Filters     []string // 该组特定的过滤词（虽然原始实现是全局的，但这里保留扩展性）
+	GroupKey    string
+	Priority    int                // 优先级：1-10，默认5，越高越重要
+	Lists       []KeywordList      // 引用的外部词表，加载后作为普通词并入该组
+	Tags        []string           // 组标签，供链接过滤规则的 unless_tags 使用
+	Weights     map[string]float64 // 关键词权重（word^3 语法），未设置的词权重为1
+	Description string             // 组描述，语义匹配时与标题比较相似度
+}
+
+// KeywordList 外部词表引用，对应关键词文件中的 [list:来源 选项...] 行
+type KeywordList struct {
+	Source  string        // 本地文件路径或 http(s) URL
+	Column  string        // CSV 列名或从1开始的列序号，默认第1列
+	Header  bool          // 首行是否为表头（按列名取值时自动视为有表头）
+	Format  string        // csv、tsv 或 txt（每行一个词），默认按扩展名识别
+	Refresh time.Duration // 刷新间隔，默认1小时
 }
 
 // GlobalConfig 全局配置管理器
@@ -182,6 +318,10 @@ func loadKeywords(path string) ([]KeywordGroup, []string, error) {
 		lines := strings.Split(rawGroup, "\n")
 		var required []string
 		var normal []string
+		var lists []KeywordList
+		var tags []string
+		var weights map[string]float64
+		var description string
 		priority := 5 // 默认优先级
 
 		// 这里 Python 原版逻辑：!开头的是过滤词。
@@ -204,28 +344,79 @@ func loadKeywords(path string) ([]KeywordGroup, []string, error) {
 				continue
 			}
 
+			// 检查是否是标签标记：[tags:video,tech]
+			if strings.HasPrefix(line, "[tags:") && strings.HasSuffix(line, "]") {
+				for _, tag := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(line, "[tags:"), "]"), ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						tags = append(tags, tag)
+					}
+				}
+				continue
+			}
+
+			// 检查是否是组描述：[desc:国产大模型与AI芯片]
+			if strings.HasPrefix(line, "[desc:") && strings.HasSuffix(line, "]") {
+				description = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "[desc:"), "]"))
+				continue
+			}
+
+			// 检查是否是外部词表引用：[list:watchlists/stocks.csv column=简称 refresh=6h]
+			if strings.HasPrefix(line, "[list:") && strings.HasSuffix(line, "]") {
+				list, err := parseKeywordList(strings.TrimSuffix(strings.TrimPrefix(line, "[list:"), "]"), filepath.Dir(path))
+				if err != nil {
+					fmt.Printf("Warning: invalid keyword list %q: %v\n", line, err)
+				} else {
+					lists = append(lists, list)
+				}
+				continue
+			}
+
 			if strings.HasPrefix(line, "!") {
 				globalFilters = append(globalFilters, strings.TrimPrefix(line, "!"))
-			} else if strings.HasPrefix(line, "+") {
-				required = append(required, strings.TrimPrefix(line, "+"))
+				continue
+			}
+
+			// 关键词权重：梁文锋^3
+			isRequired := strings.HasPrefix(line, "+")
+			word, weight := splitWeight(strings.TrimPrefix(line, "+"))
+			if weight != 1 {
+				if weights == nil {
+					weights = make(map[string]float64)
+				}
+				weights[word] = weight
+			}
+			if isRequired {
+				required = append(required, word)
 			} else {
-				normal = append(normal, line)
+				normal = append(normal, word)
 			}
 		}
 
 		key := ""
 		if len(normal) > 0 {
 			key = strings.Join(normal, " ")
-		} else {
+		} else if len(required) > 0 {
 			key = strings.Join(required, " ")
+		} else if len(lists) > 0 {
+			var sources []string
+			for _, l := range lists {
+				sources = append(sources, filepath.Base(l.Source))
+			}
+			key = strings.Join(sources, " ")
+		} else {
+			key = description
 		}
 
-		if len(required) > 0 || len(normal) > 0 {
+		if len(required) > 0 || len(normal) > 0 || len(lists) > 0 || description != "" {
 			groups = append(groups, KeywordGroup{
-				Required: required,
-				Normal:   normal,
-				GroupKey: key,
-				Priority: priority,
+				Required:    required,
+				Normal:      normal,
+				GroupKey:    key,
+				Priority:    priority,
+				Lists:       lists,
+				Tags:        tags,
+				Weights:     weights,
+				Description: description,
 			})
 		}
 	}
@@ -233,6 +424,69 @@ func loadKeywords(path string) ([]KeywordGroup, []string, error) {
 	return groups, globalFilters, nil
 }
 
+// splitWeight 拆分关键词末尾的权重后缀，如 "梁文锋^3" -> ("梁文锋", 3)
+// 没有后缀或后缀不是正数时原样返回，权重为1
+func splitWeight(word string) (string, float64) {
+	idx := strings.LastIndex(word, "^")
+	if idx <= 0 {
+		return word, 1
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSpace(word[idx+1:]), 64)
+	if err != nil || weight <= 0 {
+		return word, 1
+	}
+	return strings.TrimSpace(word[:idx]), weight
+}
+
+// parseKeywordList 解析外部词表引用，格式：来源 [column=列] [header=true] [format=csv] [refresh=6h]
+// 相对路径以关键词文件所在目录为基准
+func parseKeywordList(spec string, baseDir string) (KeywordList, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return KeywordList{}, fmt.Errorf("missing list source")
+	}
+
+	list := KeywordList{
+		Source:  fields[0],
+		Refresh: time.Hour,
+	}
+	isURL := strings.HasPrefix(list.Source, "http://") || strings.HasPrefix(list.Source, "https://")
+	if !isURL && !filepath.IsAbs(list.Source) {
+		list.Source = filepath.Join(baseDir, list.Source)
+	}
+
+	for _, opt := range fields[1:] {
+		k, v, ok := strings.Cut(opt, "=")
+		if !ok {
+			return KeywordList{}, fmt.Errorf("invalid option %q", opt)
+		}
+		switch k {
+		case "column":
+			list.Column = v
+			if _, err := strconv.Atoi(v); err != nil {
+				list.Header = true // 按列名取值时首行必然是表头
+			}
+		case "header":
+			list.Header = v == "true" || v == "1" || v == "yes"
+		case "format":
+			if v != "csv" && v != "tsv" && v != "txt" {
+				return KeywordList{}, fmt.Errorf("unsupported format %q", v)
+			}
+			list.Format = v
+		case "refresh":
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return KeywordList{}, fmt.Errorf("invalid refresh %q: %w", v, err)
+			}
+			list.Refresh = d
+		default:
+			return KeywordList{}, fmt.Errorf("unknown option %q", k)
+		}
+	}
+
+	return list, nil
+}
+
 // VersionInfo 版本信息
 type VersionInfo struct {
 	CurrentVersion string `json:"current_version"`
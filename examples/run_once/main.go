@@ -12,6 +12,7 @@ import (
 	"github.com/gotoailab/trendhub/internal/filter"
 	"github.com/gotoailab/trendhub/internal/notifier"
 	"github.com/gotoailab/trendhub/internal/rank"
+	"github.com/gotoailab/trendhub/internal/watchlist"
 )
 
 func main() {
@@ -26,16 +27,30 @@ func main() {
 	}
 	fmt.Printf("Config loaded. Platforms: %d, Keywords Groups: %d\n", len(cfg.Config.Platforms), len(cfg.KeywordGroups))
 
-	// 2. 初始化模块
-	c := crawler.NewNewsNowCrawler(cfg.Config)
-	f := filter.NewKeywordFilter(cfg.KeywordGroups, cfg.GlobalFilters)
-	r := rank.NewWeightedRanker(cfg.Config.Weight, cfg.Config.Platforms)
-	n := notifier.NewNotificationManager(cfg.Config)
-
-	// 3. 执行任务 (这里演示一次性执行，如果是守护进程可以加 for loop 或 cron)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
+	// 2. 初始化模块（展开关键词组引用的外部词表）
+	groups := watchlist.NewStore().Resolve(ctx, cfg.KeywordGroups)
+	c := crawler.NewNewsNowCrawler(cfg.Config)
+	f := filter.NewKeywordFilter(groups, cfg.GlobalFilters)
+	if err := f.SetURLRules(cfg.Config.Filter.URLRules); err != nil {
+		log.Fatalf("Invalid url rules: %v", err)
+	}
+	if cfg.Config.Filter.Semantic.Enabled {
+		if m, err := filter.NewSemanticMatcher(cfg.Config.Filter.Semantic); err != nil {
+			log.Printf("Semantic matching disabled: %v", err)
+		} else {
+			f.SetSemantic(m)
+		}
+	}
+	r, err := rank.New(rank.Options{Weight: cfg.Config.Weight, Platforms: cfg.Config.Platforms, Groups: cfg.KeywordGroups})
+	if err != nil {
+		log.Fatalf("Invalid rank strategy: %v", err)
+	}
+	n := notifier.NewNotificationManager(cfg.Config, cfg.KeywordGroups)
+
+	// 3. 执行任务 (这里演示一次性执行，如果是守护进程可以加 for loop 或 cron)
 	log.Println("Start crawling...")
 	// 3.1 爬取
 	data, err := c.Run(ctx)
@@ -64,7 +79,13 @@ func main() {
 	// 3.4 推送
 	if cfg.Config.Notification.EnableNotification {
 		log.Println("Sending notifications...")
-		n.SendAll(ctx, rankedItems)
+		for _, d := range n.SendAll(ctx, rankedItems) {
+			if d.Error != "" {
+				log.Printf("Failed to send notification via %s: %s", d.Channel, d.Error)
+			} else {
+				log.Printf("Notification sent via %s (%d messages)", d.Channel, d.Batches)
+			}
+		}
 	} else {
 		log.Println("Notification disabled")
 	}
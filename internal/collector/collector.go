@@ -184,6 +184,11 @@ func (dc *DailyCollector) collect(ctx context.Context) {
 		return
 	}
 
+	// 回填首次发现时间和出现次数
+	if err := dc.cache.TrackSeen(data, time.Now()); err != nil {
+		logger.Infof("Warning: Failed to track seen items: %v", err)
+	}
+
 	// 保存到历史记录（覆盖今天的记录，保持最新）
 	if err := dc.cache.SaveCrawlHistory(data); err != nil {
 		logger.Infof("Warning: Failed to save crawl history: %v", err)
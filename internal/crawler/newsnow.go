@@ -105,17 +105,21 @@ func (c *NewsNowCrawler) fetchPlatform(ctx context.Context, platform model.Platf
 		return nil, err
 	}
 
+	now := time.Now()
 	var newsItems []*model.NewsItem
 	for i, item := range apiResp.Items {
 		newsItems = append(newsItems, &model.NewsItem{
-			Title:      item.Title,
-			URL:        item.URL,
-			MobileURL:  item.MobileURL,
-			Ranks:      []int{i + 1}, // 原始排名
-			SourceID:   platform.ID,
-			SourceName: platform.Name,
-			FirstSeen:  time.Now().Format("15:04"), // 简单记录时间
-			IsNew:      true, // 初始默认为新，后续由 Filter 模块判断
+			Title:       item.Title,
+			URL:         item.URL,
+			MobileURL:   item.MobileURL,
+			Ranks:       []int{i + 1}, // 原始排名
+			BoardSize:   len(apiResp.Items),
+			SourceID:    platform.ID,
+			SourceName:  platform.Name,
+			FirstSeen:   now.Format("15:04"), // 简单记录时间，跨抓取的真实首次发现时间由数据缓存回填
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+			IsNew:       true, // 初始默认为新，后续由数据缓存按出现记录判断
 		})
 	}
 
@@ -17,6 +17,10 @@ const (
 	dailyBucket       = "daily_cache"
 	incrementalBucket = "incremental_pushed"
 	historyBucket     = "crawl_history" // 存储每天的抓取历史
+	seenBucket        = "seen_items"    // 存储每条新闻的首次/最后发现时间
+
+	seenRetention     = 3 * 24 * time.Hour // 超过该时长未再出现的新闻从 seen_items 中清理
+	seenCleanInterval = time.Hour          // seen_items 的清理间隔
 )
 
 // DataCache 数据缓存管理器
@@ -26,6 +30,7 @@ type DataCache struct {
 	dailyCache      map[string]*model.NewsItem // 当日汇总缓存（内存）
 	lastResetTime   time.Time                  // 上次重置时间
 	incrementalMode bool                       // 是否启用增量模式
+	lastSeenClean   time.Time                  // 上次清理 seen_items 的时间
 }
 
 // NewDataCache 创建数据缓存
@@ -46,6 +51,9 @@ func NewDataCache(dbPath string) (*DataCache, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(historyBucket)); err != nil {
 			return err
 		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(seenBucket)); err != nil {
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -62,6 +70,19 @@ func NewDataCache(dbPath string) (*DataCache, error) {
 	return cache, nil
 }
 
+// OpenReadOnly 以只读方式打开数据缓存，用于离线分析历史数据
+// bbolt 不允许与读写进程同时打开同一文件，服务运行时请先复制数据库文件
+func OpenReadOnly(dbPath string) (*DataCache, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+	return &DataCache{
+		db:         db,
+		dailyCache: make(map[string]*model.NewsItem),
+	}, nil
+}
+
 // Close 关闭数据库
 func (dc *DataCache) Close() error {
 	return dc.db.Close()
@@ -99,13 +120,113 @@ func (dc *DataCache) AddToDailyCache(items []*model.NewsItem) int {
 			existing := dc.dailyCache[hash]
 			if len(item.Ranks) > 0 && (len(existing.Ranks) == 0 || item.Ranks[0] < existing.Ranks[0]) {
 				existing.Ranks = item.Ranks
+				existing.BoardSize = item.BoardSize
 			}
+			// 同步最新的出现信息
+			if item.LastSeenAt.After(existing.LastSeenAt) {
+				existing.LastSeenAt = item.LastSeenAt
+				existing.LastSeen = item.LastSeen
+			}
+			existing.IsNew = item.IsNew
 		}
 	}
 
 	return addedCount
 }
 
+// seenRecord 新闻的出现记录
+type seenRecord struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int       `json:"count"` // 保留期内的抓取次数，只用于判断是否新增
+}
+
+// TrackSeen 记录本次抓取到的新闻，并回填首次/最后发现时间和是否新增
+// 不修改出现次数 AppearCount：累计次数会让长期在榜的新闻频次分更高
+// 每次抓取后调用一次，同一条新闻（标题+平台）在多次抓取间共享首次发现时间
+func (dc *DataCache) TrackSeen(data map[string][]*model.NewsItem, now time.Time) error {
+	err := dc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(seenBucket))
+		for _, items := range data {
+			for _, item := range items {
+				key := []byte(generateHash(item))
+
+				var record seenRecord
+				if v := b.Get(key); v != nil {
+					if err := json.Unmarshal(v, &record); err != nil {
+						record = seenRecord{}
+					}
+				}
+				if record.FirstSeen.IsZero() || now.Sub(record.LastSeen) > seenRetention {
+					record = seenRecord{FirstSeen: now}
+				}
+				if !record.LastSeen.Equal(now) {
+					record.Count++
+				}
+				record.LastSeen = now
+
+				value, err := json.Marshal(record)
+				if err != nil {
+					return err
+				}
+				if err := b.Put(key, value); err != nil {
+					return err
+				}
+
+				item.FirstSeenAt = record.FirstSeen
+				item.LastSeenAt = record.LastSeen
+				item.FirstSeen = record.FirstSeen.Format("15:04")
+				item.LastSeen = record.LastSeen.Format("15:04")
+				item.IsNew = record.Count == 1
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dc.mu.Lock()
+	needClean := now.Sub(dc.lastSeenClean) >= seenCleanInterval
+	if needClean {
+		dc.lastSeenClean = now
+	}
+	dc.mu.Unlock()
+
+	if needClean {
+		if deleted, err := dc.cleanSeen(now); err != nil {
+			logger.Errorf("Failed to clean seen items: %v", err)
+		} else if deleted > 0 {
+			logger.Infof("Cleaned %d stale seen items", deleted)
+		}
+	}
+	return nil
+}
+
+// cleanSeen 清理长时间未再出现的新闻记录
+func (dc *DataCache) cleanSeen(now time.Time) (int, error) {
+	deleted := 0
+	err := dc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(seenBucket))
+		var stale [][]byte
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var record seenRecord
+			if err := json.Unmarshal(v, &record); err != nil || now.Sub(record.LastSeen) > seenRetention {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
 // GetDailyCache 获取当日缓存的所有数据
 func (dc *DataCache) GetDailyCache() []*model.NewsItem {
 	dc.mu.RLock()
@@ -325,6 +446,22 @@ func (dc *DataCache) GetCrawlHistory(date string) (*CrawlHistoryRecord, error) {
 	return &record, nil
 }
 
+// CrawlHistoryDates 返回有抓取历史的日期（YYYY-MM-DD），按时间从早到晚排列
+func (dc *DataCache) CrawlHistoryDates() ([]string, error) {
+	var dates []string
+	err := dc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(historyBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			dates = append(dates, string(k))
+			return nil
+		})
+	})
+	return dates, err
+}
+
 // GetRecentCrawlHistory 获取最近N天的抓取历史列表（仅摘要）
 func (dc *DataCache) GetRecentCrawlHistory(days int) ([]map[string]interface{}, error) {
 	var histories []map[string]interface{}
@@ -0,0 +1,175 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider 文本向量化服务
+type Provider interface {
+	// Embed 返回每段文本的向量，顺序与入参一致
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Name() string
+}
+
+// NewProvider 按名称创建向量化服务
+// provider: openai（兼容 OpenAI /embeddings 接口的服务）或 ollama（本地模型服务）
+func NewProvider(provider, baseURL, apiKey, model string, timeout time.Duration) (Provider, error) {
+	if model == "" {
+		return nil, fmt.Errorf("embedding model is empty")
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch strings.ToLower(provider) {
+	case "", "openai":
+		return NewOpenAIProvider(baseURL, apiKey, model, client), nil
+	case "ollama":
+		return NewOllamaProvider(baseURL, model, client), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", provider)
+	}
+}
+
+// OpenAIProvider 兼容 OpenAI 的向量化接口（OpenAI、Azure 代理、vLLM、LM Studio 等）
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIProvider 创建 OpenAI 兼容的向量化服务
+// baseURL: 接口地址，如 https://api.openai.com/v1
+func NewOpenAIProvider(baseURL, apiKey, model string, client *http.Client) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	body := map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	}
+	if err := postJSON(ctx, p.client, p.baseURL+"/embeddings", p.apiKey, body, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index out of range: %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// OllamaProvider Ollama 本地模型服务
+type OllamaProvider struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOllamaProvider 创建 Ollama 向量化服务
+// baseURL: 服务地址，如 http://localhost:11434
+func NewOllamaProvider(baseURL, model string, client *http.Client) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	return &OllamaProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  client,
+	}
+}
+
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	body := map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	}
+	if err := postJSON(ctx, p.client, p.baseURL+"/api/embed", "", body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+	return resp.Embeddings, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d, body: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,119 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		model    string
+		want     string
+		wantErr  bool
+	}{
+		{"", "text-embedding-3-small", "openai", false},
+		{"OpenAI", "text-embedding-3-small", "openai", false},
+		{"ollama", "bge-m3", "ollama", false},
+		{"cohere", "embed", "", true},
+		{"openai", "", "", true},
+	}
+	for _, tt := range tests {
+		p, err := NewProvider(tt.provider, "", "", tt.model, time.Second)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewProvider(%q, %q) error = %v", tt.provider, tt.model, err)
+			continue
+		}
+		if err == nil && p.Name() != tt.want {
+			t.Errorf("NewProvider(%q) = %s, want %s", tt.provider, p.Name(), tt.want)
+		}
+	}
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s, auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "m" || len(req.Input) != 2 {
+			t.Errorf("unexpected body %+v", req)
+		}
+		// 返回顺序与输入不同，按 index 对应
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(server.URL+"/v1/", "sk-test", "m", server.Client())
+	vectors, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("vectors = %v, want them ordered by index", vectors)
+	}
+}
+
+func TestEmbedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		ollama  bool
+		wantErr string
+	}{
+		{"status", http.StatusUnauthorized, `{"error":"invalid api key"}`, false, "status code: 401"},
+		{"missing embedding", http.StatusOK, `{"data":[{"index":0,"embedding":[1]}]}`, false, "missing embedding for input 1"},
+		{"index out of range", http.StatusOK, `{"data":[{"index":5,"embedding":[1]}]}`, false, "out of range"},
+		{"bad json", http.StatusOK, `not json`, false, "decode response failed"},
+		{"ollama count", http.StatusOK, `{"embeddings":[[1]]}`, true, "expected 2 embeddings, got 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var p Provider = NewOpenAIProvider(server.URL, "", "m", server.Client())
+			if tt.ollama {
+				p = NewOllamaProvider(server.URL, "m", server.Client())
+			}
+			_, err := p.Embed(context.Background(), []string{"a", "b"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Embed error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbedTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	p, err := NewProvider("ollama", server.URL, "", "m", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := p.Embed(context.Background(), []string{"a"}); err == nil {
+		t.Fatal("Embed should fail after the timeout")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Embed returned after %v, want the 50ms timeout", d)
+	}
+}
@@ -0,0 +1,339 @@
+package feedback
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+	"github.com/gotoailab/trendhub/internal/rank"
+)
+
+// 配置项为0时使用的默认值
+const (
+	DefaultStrength = 0.5 // 学习强度：系数在 [1-strength, 1+strength] 之间
+	DefaultMinVotes = 5   // 平台或关键词组参与调整所需的最少反馈数
+	DefaultDays     = 30  // 参与学习的反馈天数
+
+	DefaultMuteHours = 24 // 静音关键词组的时长
+)
+
+// Stat 一个平台或关键词组收到的反馈
+type Stat struct {
+	Up         int     `json:"up"`
+	Down       int     `json:"down"`
+	Clicks     int     `json:"clicks"`     // 推送链接的点击次数，不参与系数计算
+	Multiplier float64 `json:"multiplier"` // 学习得到的系数，反馈不足时为1
+}
+
+// Days 返回参与学习的反馈天数，更早的反馈不再使用
+func Days(cfg config.FeedbackConfig) int {
+	if cfg.Days <= 0 {
+		return DefaultDays
+	}
+	return cfg.Days
+}
+
+// MuteHours 返回推送中的静音按钮暂停推送关键词组的小时数
+func MuteHours(cfg config.FeedbackConfig) int {
+	if cfg.MuteHours <= 0 {
+		return DefaultMuteHours
+	}
+	return cfg.MuteHours
+}
+
+// Model 从反馈中学习得到的调整系数
+type Model struct {
+	Votes     int              `json:"votes"`
+	Clicks    int              `json:"clicks"`
+	Platforms map[string]*Stat `json:"platforms"`
+	Groups    map[string]*Stat `json:"groups"`
+
+	records  []*pushdb.Feedback
+	strength float64
+	minVotes int
+}
+
+// Learn 汇总反馈，计算各平台和关键词组的系数
+// “有用”比例按拉普拉斯平滑估计：p = (有用+1) / (总数+2)，系数 = 1 + strength × (2p - 1)
+// 推送链接的点击只说明读者打开了新闻，单独计数，不计入“有用”
+func Learn(records []*pushdb.Feedback, cfg config.FeedbackConfig) *Model {
+	m := &Model{
+		Platforms: make(map[string]*Stat),
+		Groups:    make(map[string]*Stat),
+		records:   records,
+		strength:  cfg.Strength,
+		minVotes:  cfg.MinVotes,
+	}
+	if m.strength <= 0 || m.strength > 1 {
+		m.strength = DefaultStrength
+	}
+	if m.minVotes <= 0 {
+		m.minVotes = DefaultMinVotes
+	}
+
+	for _, fb := range records {
+		// 早期版本把点击记为“有用”，按来源识别
+		if fb.Source == pushdb.FeedbackSourceLink {
+			m.Clicks++
+			stat(m.Platforms, fb.SourceID).Clicks++
+			if fb.KeywordGroup != "" {
+				stat(m.Groups, fb.KeywordGroup).Clicks++
+			}
+			continue
+		}
+		if fb.Vote == 0 {
+			continue
+		}
+		m.Votes++
+		count(m.Platforms, fb.SourceID, fb.Vote)
+		if fb.KeywordGroup != "" {
+			count(m.Groups, fb.KeywordGroup, fb.Vote)
+		}
+	}
+	for _, stats := range []map[string]*Stat{m.Platforms, m.Groups} {
+		for _, s := range stats {
+			s.Multiplier = m.multiplier(s.Up, s.Down)
+		}
+	}
+	return m
+}
+
+func stat(stats map[string]*Stat, key string) *Stat {
+	s, ok := stats[key]
+	if !ok {
+		s = &Stat{}
+		stats[key] = s
+	}
+	return s
+}
+
+func count(stats map[string]*Stat, key string, vote int) {
+	s := stat(stats, key)
+	if vote > 0 {
+		s.Up++
+	} else {
+		s.Down++
+	}
+}
+
+func (m *Model) multiplier(up, down int) float64 {
+	n := up + down
+	if n < m.minVotes {
+		return 1
+	}
+	p := float64(up+1) / float64(n+2)
+	return round(1 + m.strength*(2*p-1))
+}
+
+// Boosts 返回供 feedback 排序策略使用的调整系数
+func (m *Model) Boosts() *rank.Boosts {
+	b := &rank.Boosts{
+		Platforms: make(map[string]float64, len(m.Platforms)),
+		Groups:    make(map[string]float64, len(m.Groups)),
+	}
+	for id, s := range m.Platforms {
+		b.Platforms[id] = s.Multiplier
+	}
+	for key, s := range m.Groups {
+		b.Groups[key] = s.Multiplier
+	}
+	return b
+}
+
+// PlatformSuggestion 平台权重调整建议
+type PlatformSuggestion struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Up        int     `json:"up"`
+	Down      int     `json:"down"`
+	Clicks    int     `json:"clicks"`
+	Current   float64 `json:"current"`
+	Suggested float64 `json:"suggested"`
+}
+
+// GroupSuggestion 关键词组调整系数，只在 feedback 排序策略下生效
+type GroupSuggestion struct {
+	Group      string  `json:"group"`
+	Up         int     `json:"up"`
+	Down       int     `json:"down"`
+	Clicks     int     `json:"clicks"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Suggestion 根据反馈给出的配置调整建议，确认后由 Apply 写入配置
+type Suggestion struct {
+	Votes     int                  `json:"votes"`
+	Clicks    int                  `json:"clicks"`
+	MinVotes  int                  `json:"min_votes"`
+	Platforms []PlatformSuggestion `json:"platforms"`
+	Groups    []GroupSuggestion    `json:"groups"`
+	Current   config.WeightConfig  `json:"current"`
+	Suggested config.WeightConfig  `json:"suggested"`
+	Changed   bool                 `json:"changed"` // 建议与当前配置是否不同
+
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // 上次应用建议时已学习的最新反馈时间，建议只使用之后的反馈
+	Latest    time.Time  `json:"-"`                    // 本次建议使用的最新反馈时间，应用后记为新的 AppliedAt
+}
+
+// Suggest 生成权重调整建议
+// 平台权重按平台系数缩放；各项权重按“有用”和“没用”新闻在该项上的平均得分占比之差调整，调整后总和保持不变
+func (m *Model) Suggest(cfg *config.Config) *Suggestion {
+	s := &Suggestion{
+		Votes:     m.Votes,
+		Clicks:    m.Clicks,
+		MinVotes:  m.minVotes,
+		Current:   effectiveWeights(cfg.Weight),
+		Suggested: effectiveWeights(cfg.Weight),
+	}
+	for _, fb := range m.records {
+		if fb.Timestamp.After(s.Latest) {
+			s.Latest = fb.Timestamp
+		}
+	}
+
+	for _, p := range cfg.Platforms {
+		current := p.Weight
+		if current <= 0 {
+			current = 1.0
+		}
+		ps := PlatformSuggestion{ID: p.ID, Name: p.Name, Current: current, Suggested: current}
+		if stat, ok := m.Platforms[p.ID]; ok {
+			ps.Up, ps.Down, ps.Clicks = stat.Up, stat.Down, stat.Clicks
+			ps.Suggested = math.Min(2, round(current*stat.Multiplier))
+		}
+		if ps.Suggested != ps.Current {
+			s.Changed = true
+		}
+		s.Platforms = append(s.Platforms, ps)
+	}
+
+	for key, stat := range m.Groups {
+		s.Groups = append(s.Groups, GroupSuggestion{Group: key, Up: stat.Up, Down: stat.Down, Clicks: stat.Clicks, Multiplier: stat.Multiplier})
+	}
+	sort.Slice(s.Groups, func(i, j int) bool {
+		ni, nj := s.Groups[i].Up+s.Groups[i].Down, s.Groups[j].Up+s.Groups[j].Down
+		if ni != nj {
+			return ni > nj
+		}
+		return s.Groups[i].Group < s.Groups[j].Group
+	})
+
+	if m.suggestWeights(&s.Suggested) {
+		s.Changed = true
+	}
+	return s
+}
+
+// effectiveWeights 返回排序时实际使用的权重：关键词和时效性权重为0时与排序策略一样使用默认值
+func effectiveWeights(w config.WeightConfig) config.WeightConfig {
+	if w.KeywordWeight == 0 {
+		w.KeywordWeight = rank.DefaultKeywordWeight
+	}
+	if w.FreshnessWeight == 0 {
+		w.FreshnessWeight = rank.DefaultFreshnessWeight
+	}
+	return w
+}
+
+// component 参与权重学习的一项得分
+type component struct {
+	weight       *float64
+	contribution func(*model.ScoreBreakdown) float64
+}
+
+// suggestWeights 按反馈调整排名、频次、关键词、时效性、跨平台共识的权重，返回是否有变化
+// w 为 effectiveWeights 处理后的权重，为0的项在排序中不生效，不参与调整
+func (m *Model) suggestWeights(w *config.WeightConfig) bool {
+	components := []component{
+		{&w.RankWeight, func(s *model.ScoreBreakdown) float64 { return s.Rank }},
+		{&w.FrequencyWeight, func(s *model.ScoreBreakdown) float64 { return s.Frequency }},
+		{&w.KeywordWeight, func(s *model.ScoreBreakdown) float64 { return s.Keyword }},
+		{&w.FreshnessWeight, func(s *model.ScoreBreakdown) float64 { return s.Freshness }},
+		{&w.ConsensusWeight, func(s *model.ScoreBreakdown) float64 { return s.Consensus }},
+	}
+
+	// 各项在“有用”和“没用”新闻中的平均得分占比
+	upShare := make([]float64, len(components))
+	downShare := make([]float64, len(components))
+	var up, down int
+	for _, fb := range m.records {
+		if fb.Score == nil || fb.Score.Base <= 0 || fb.Vote == 0 {
+			continue
+		}
+		share := upShare
+		if fb.Vote > 0 {
+			up++
+		} else {
+			down++
+			share = downShare
+		}
+		for i, c := range components {
+			share[i] += c.contribution(fb.Score) / fb.Score.Base
+		}
+	}
+	if up < m.minVotes || down < m.minVotes {
+		return false
+	}
+
+	before, after := 0.0, 0.0
+	adjusted := make([]float64, len(components))
+	for i, c := range components {
+		if *c.weight <= 0 {
+			continue // 未启用的项不参与调整
+		}
+		delta := upShare[i]/float64(up) - downShare[i]/float64(down) // [-1, 1]
+		factor := 1 + m.strength*math.Max(-1, math.Min(1, 2*delta))
+		before += *c.weight
+		adjusted[i] = *c.weight * factor
+		after += adjusted[i]
+	}
+	if after <= 0 {
+		return false
+	}
+
+	changed := false
+	for i, c := range components {
+		if *c.weight <= 0 {
+			continue
+		}
+		v := round(adjusted[i] * before / after)
+		if v != *c.weight {
+			*c.weight = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Pending 返回上次应用建议之后的反馈
+// 调整建议在当前配置的基础上缩放权重，只从新的反馈中学习，重复应用同一批反馈的建议不会再次调整权重
+func Pending(records []*pushdb.Feedback, applied time.Time) []*pushdb.Feedback {
+	var pending []*pushdb.Feedback
+	for _, fb := range records {
+		if fb.Timestamp.After(applied) {
+			pending = append(pending, fb)
+		}
+	}
+	return pending
+}
+
+// Apply 将建议写入配置：平台权重和 weight 下的各项权重
+func Apply(cfg *config.Config, s *Suggestion) {
+	suggested := make(map[string]float64, len(s.Platforms))
+	for _, p := range s.Platforms {
+		suggested[p.ID] = p.Suggested
+	}
+	for i := range cfg.Platforms {
+		if w, ok := suggested[cfg.Platforms[i].ID]; ok {
+			cfg.Platforms[i].Weight = w
+		}
+	}
+	cfg.Weight = s.Suggested
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
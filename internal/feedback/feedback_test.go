@@ -0,0 +1,164 @@
+package feedback
+
+import (
+	"math"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+	"github.com/gotoailab/trendhub/internal/rank"
+)
+
+func TestIsLinkPreview(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want bool
+	}{
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"TelegramBot (like TwitterBot)", true},
+		{"facebookexternalhit/1.1", true},
+		{"WhatsApp/2.23.20.0", true},
+		{"", true},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Lark/7.1.0", false},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", false},
+	}
+	for _, tt := range tests {
+		if got := IsLinkPreview(tt.ua); got != tt.want {
+			t.Errorf("IsLinkPreview(%q) = %v, want %v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestParseClick(t *testing.T) {
+	l := NewLinker("https://trendhub.example.com/", []byte("secret"))
+	item := &model.NewsItem{Title: "标题", URL: "https://example.com/1", SourceID: "weibo", KeywordGroupKey: "AI"}
+	u, err := url.Parse(l.URL(item, item.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fb, target, err := l.Parse(u.Query())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != item.URL || fb.Vote != 0 || fb.Source != pushdb.FeedbackSourceLink || fb.KeywordGroup != "AI" {
+		t.Errorf("unexpected click feedback %+v, target %q", fb, target)
+	}
+
+	q := u.Query()
+	q.Set("u", "https://evil.example.com")
+	if _, _, err := l.Parse(q); err == nil {
+		t.Error("tampered link should be rejected")
+	}
+}
+
+func TestLearnCountsClicksSeparately(t *testing.T) {
+	var records []*pushdb.Feedback
+	for i := 0; i < 10; i++ {
+		// 新版本记录的点击为0，早期版本记为1，都只计为点击
+		records = append(records, &pushdb.Feedback{Vote: i % 2, Source: pushdb.FeedbackSourceLink, SourceID: "weibo", KeywordGroup: "AI"})
+	}
+	for i := 0; i < 5; i++ {
+		records = append(records, &pushdb.Feedback{Vote: -1, Source: pushdb.FeedbackSourceWeb, SourceID: "weibo"})
+	}
+
+	m := Learn(records, config.FeedbackConfig{})
+	if m.Votes != 5 || m.Clicks != 10 {
+		t.Errorf("votes = %d, clicks = %d, want 5 and 10", m.Votes, m.Clicks)
+	}
+	s := m.Platforms["weibo"]
+	if s.Up != 0 || s.Down != 5 || s.Clicks != 10 {
+		t.Errorf("weibo stat = %+v", s)
+	}
+	if s.Multiplier >= 1 {
+		t.Errorf("clicks counted as up-votes: multiplier %.2f", s.Multiplier)
+	}
+	if g := m.Groups["AI"]; g.Clicks != 10 || g.Multiplier != 1 {
+		t.Errorf("group stat = %+v", g)
+	}
+}
+
+func TestSuggestWeightsUsesDefaults(t *testing.T) {
+	var records []*pushdb.Feedback
+	for i := 0; i < 5; i++ {
+		// “有用”的新闻主要靠关键词得分，“没用”的主要靠排名得分
+		records = append(records,
+			&pushdb.Feedback{Vote: 1, Source: pushdb.FeedbackSourceWeb, Score: &model.ScoreBreakdown{Rank: 20, Keyword: 80, Base: 100}},
+			&pushdb.Feedback{Vote: -1, Source: pushdb.FeedbackSourceWeb, Score: &model.ScoreBreakdown{Rank: 80, Keyword: 20, Base: 100}},
+		)
+	}
+	cfg := &config.Config{Weight: config.WeightConfig{RankWeight: 0.6, FrequencyWeight: 0.3}}
+
+	s := Learn(records, config.FeedbackConfig{}).Suggest(cfg)
+	if !s.Changed {
+		t.Fatal("expected a suggestion")
+	}
+	if s.Current.KeywordWeight != rank.DefaultKeywordWeight || s.Current.FreshnessWeight != rank.DefaultFreshnessWeight {
+		t.Errorf("current weights not resolved to defaults: %+v", s.Current)
+	}
+	if s.Suggested.KeywordWeight <= rank.DefaultKeywordWeight || s.Suggested.RankWeight >= 0.6 {
+		t.Errorf("keyword weight should rise and rank weight fall: %+v", s.Suggested)
+	}
+	if s.Suggested.ConsensusWeight != 0 {
+		t.Errorf("disabled consensus weight adjusted to %.2f", s.Suggested.ConsensusWeight)
+	}
+	w := s.Suggested
+	if sum := w.RankWeight + w.FrequencyWeight + w.KeywordWeight + w.FreshnessWeight; math.Abs(sum-1.3) > 0.02 {
+		t.Errorf("weights sum to %.2f, want about 1.3", sum)
+	}
+}
+
+func TestApplyTwiceIsIdempotent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	var records []*pushdb.Feedback
+	for i := 0; i < 6; i++ {
+		records = append(records,
+			&pushdb.Feedback{Timestamp: start.Add(time.Duration(2*i) * time.Minute), Vote: 1, Source: pushdb.FeedbackSourceWeb, SourceID: "zhihu",
+				Score: &model.ScoreBreakdown{Rank: 20, Keyword: 80, Base: 100}},
+			&pushdb.Feedback{Timestamp: start.Add(time.Duration(2*i+1) * time.Minute), Vote: -1, Source: pushdb.FeedbackSourceWeb, SourceID: "weibo",
+				Score: &model.ScoreBreakdown{Rank: 80, Keyword: 20, Base: 100}},
+		)
+	}
+	cfg := &config.Config{
+		Platforms: []model.Platform{{ID: "zhihu"}, {ID: "weibo"}},
+		Weight:    config.WeightConfig{RankWeight: 0.6, FrequencyWeight: 0.3},
+	}
+
+	// 第一次应用：所有反馈都是新的
+	var applied time.Time
+	s := Learn(Pending(records, applied), config.FeedbackConfig{}).Suggest(cfg)
+	if !s.Changed {
+		t.Fatal("expected a suggestion")
+	}
+	Apply(cfg, s)
+	applied = s.Latest
+	if want := records[len(records)-1].Timestamp; !applied.Equal(want) {
+		t.Fatalf("Latest = %v, want %v", applied, want)
+	}
+	first := *cfg
+	first.Platforms = append([]model.Platform(nil), cfg.Platforms...)
+
+	// 没有新反馈时再次应用，配置不变
+	s = Learn(Pending(records, applied), config.FeedbackConfig{}).Suggest(cfg)
+	if s.Changed || s.Votes != 0 {
+		t.Fatalf("second suggestion changed = %v with %d votes, want no change", s.Changed, s.Votes)
+	}
+	Apply(cfg, s)
+	if !reflect.DeepEqual(cfg.Weight, first.Weight) || !reflect.DeepEqual(cfg.Platforms, first.Platforms) {
+		t.Errorf("second apply drifted: weights %+v -> %+v, platforms %+v -> %+v", first.Weight, cfg.Weight, first.Platforms, cfg.Platforms)
+	}
+
+	// 之后的新反馈仍然参与学习
+	for i := 0; i < 5; i++ {
+		records = append(records, &pushdb.Feedback{Timestamp: applied.Add(time.Duration(i+1) * time.Minute), Vote: -1, Source: pushdb.FeedbackSourceWeb, SourceID: "zhihu"})
+	}
+	s = Learn(Pending(records, applied), config.FeedbackConfig{}).Suggest(cfg)
+	if s.Votes != 5 || !s.Changed {
+		t.Errorf("new votes: votes = %d, changed = %v", s.Votes, s.Changed)
+	}
+}
@@ -0,0 +1,152 @@
+package feedback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+)
+
+// 推送消息中链接的路径
+const (
+	ClickPath = "/api/feedback/click" // 跟踪链接
+	MutePath  = "/api/feedback/mute"  // 静音关键词组的链接
+)
+
+// Linker 生成和校验推送消息中的跟踪链接
+// 链接参数带有 HMAC 签名，防止被用作任意跳转
+type Linker struct {
+	publicURL string
+	secret    []byte
+}
+
+// NewLinker 创建跟踪链接生成器，publicURL 为 Web 界面的外部访问地址
+func NewLinker(publicURL string, secret []byte) *Linker {
+	return &Linker{
+		publicURL: strings.TrimRight(publicURL, "/"),
+		secret:    secret,
+	}
+}
+
+// Wrap 返回链接替换为跟踪链接的新闻副本，不修改原始新闻
+func (l *Linker) Wrap(items []*model.NewsItem) []*model.NewsItem {
+	wrapped := make([]*model.NewsItem, len(items))
+	for i, item := range items {
+		copied := *item
+		if item.URL != "" {
+			copied.URL = l.URL(item, item.URL)
+		}
+		if item.MobileURL != "" {
+			copied.MobileURL = l.URL(item, item.MobileURL)
+		}
+		wrapped[i] = &copied
+	}
+	return wrapped
+}
+
+// URL 生成指向 target 的跟踪链接
+func (l *Linker) URL(item *model.NewsItem, target string) string {
+	q := url.Values{}
+	q.Set("u", target)
+	q.Set("t", item.Title)
+	q.Set("s", item.SourceID)
+	if item.KeywordGroupKey != "" {
+		q.Set("g", item.KeywordGroupKey)
+	}
+	q.Set("sig", l.sign(q))
+	return l.publicURL + ClickPath + "?" + q.Encode()
+}
+
+// Parse 校验跟踪链接参数，返回要记录的反馈和跳转地址
+func (l *Linker) Parse(q url.Values) (*pushdb.Feedback, string, error) {
+	target := q.Get("u")
+	if target == "" {
+		return nil, "", errors.New("missing target url")
+	}
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(l.sign(q))) {
+		return nil, "", errors.New("invalid signature")
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, "", errors.New("invalid target url")
+	}
+
+	fb := &pushdb.Feedback{
+		Vote:         0, // 点击只说明读者打开了新闻，不代表有用，单独计数
+		Source:       pushdb.FeedbackSourceLink,
+		Title:        q.Get("t"),
+		URL:          target,
+		SourceID:     q.Get("s"),
+		KeywordGroup: q.Get("g"),
+	}
+	return fb, target, nil
+}
+
+// linkPreviewAgents 聊天软件生成链接预览、搜索引擎抓取页面时 User-Agent 中的关键字（小写）
+// 如 Slackbot-LinkExpanding、Discordbot、TelegramBot、facebookexternalhit、WhatsApp
+var linkPreviewAgents = []string{"bot", "spider", "crawler", "preview", "facebookexternalhit", "whatsapp", "embedly", "slack-imgproxy"}
+
+// IsLinkPreview 判断请求是否来自链接预览或爬虫，这些请求不是读者的点击，不记录反馈
+// 浏览器总会发送 User-Agent，为空时同样视为程序请求
+func IsLinkPreview(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return true
+	}
+	for _, keyword := range linkPreviewAgents {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// MuteURL 生成静音关键词组 hours 小时的链接
+func (l *Linker) MuteURL(group string, hours int) string {
+	q := url.Values{}
+	q.Set("g", group)
+	q.Set("h", strconv.Itoa(hours))
+	q.Set("sig", l.signMute(q))
+	return l.publicURL + MutePath + "?" + q.Encode()
+}
+
+// ParseMute 校验静音链接参数，返回关键词组和静音的小时数
+func (l *Linker) ParseMute(q url.Values) (string, int, error) {
+	group := q.Get("g")
+	if group == "" {
+		return "", 0, errors.New("missing keyword group")
+	}
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(l.signMute(q))) {
+		return "", 0, errors.New("invalid signature")
+	}
+	hours, err := strconv.Atoi(q.Get("h"))
+	if err != nil || hours <= 0 {
+		return "", 0, errors.New("invalid mute hours")
+	}
+	return group, hours, nil
+}
+
+func (l *Linker) sign(q url.Values) string {
+	mac := hmac.New(sha256.New, l.secret)
+	for _, key := range []string{"u", "t", "s", "g"} {
+		mac.Write([]byte(q.Get(key)))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// signMute 静音链接的签名，以 mute 开头，与跟踪链接的签名区分
+func (l *Linker) signMute(q url.Values) string {
+	mac := hmac.New(sha256.New, l.secret)
+	for _, v := range []string{"mute", q.Get("g"), q.Get("h")} {
+		mac.Write([]byte(v))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
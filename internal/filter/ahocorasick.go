@@ -0,0 +1,120 @@
+package filter
+
+// acNode Aho-Corasick 自动机节点
+type acNode struct {
+	next   map[rune]int32 // 转移边
+	fail   int32          // 失配指针
+	output []int32        // 以该节点结尾的模式串ID（已合并失配链上的输出）
+}
+
+// Matcher 基于 Aho-Corasick 自动机的多模式匹配器
+// 一次扫描标题即可找出所有命中的关键词，复杂度与关键词数量无关
+type Matcher struct {
+	nodes    []acNode
+	patterns []string
+}
+
+// NewMatcher 构建匹配器，patterns 应已统一转为小写
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{
+		nodes:    []acNode{{next: make(map[rune]int32)}},
+		patterns: patterns,
+	}
+
+	// 1. 构建 Trie
+	for id, p := range patterns {
+		cur := int32(0)
+		for _, r := range p {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				m.nodes = append(m.nodes, acNode{next: make(map[rune]int32)})
+				nxt = int32(len(m.nodes) - 1)
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].output = append(m.nodes[cur].output, int32(id))
+	}
+
+	// 2. BFS 计算失配指针
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		m.nodes[child].fail = 0
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for {
+				if nxt, ok := m.nodes[f].next[r]; ok {
+					m.nodes[child].fail = nxt
+					break
+				}
+				if f == 0 {
+					m.nodes[child].fail = 0
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			// 合并失配节点的输出，匹配时无需再沿失配链回溯
+			failOut := m.nodes[m.nodes[child].fail].output
+			if len(failOut) > 0 {
+				m.nodes[child].output = append(m.nodes[child].output, failOut...)
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+// Len 返回模式串数量
+func (m *Matcher) Len() int {
+	return len(m.patterns)
+}
+
+// Match 扫描文本（应已转为小写），返回每个模式串是否命中
+func (m *Matcher) Match(text string) []bool {
+	hits := make([]bool, len(m.patterns))
+	m.MatchInto(text, hits, nil)
+	return hits
+}
+
+// MatchInto 同 Match，但复用调用方提供的结果切片（长度需等于 Len()）
+// 命中的模式串ID按首次命中顺序追加到 ids 并返回，便于调用方只处理命中部分
+func (m *Matcher) MatchInto(text string, hits []bool, ids []int32) []int32 {
+	for i := range hits {
+		hits[i] = false
+	}
+
+	// 空模式串与 strings.Contains 语义保持一致：总是命中
+	for _, id := range m.nodes[0].output {
+		if !hits[id] {
+			hits[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	cur := int32(0)
+	for _, r := range text {
+		for {
+			if nxt, ok := m.nodes[cur].next[r]; ok {
+				cur = nxt
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		for _, id := range m.nodes[cur].output {
+			if !hits[id] {
+				hits[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -1,6 +1,9 @@
 package filter
 
 import (
+	"context"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/gotoailab/trendhub/config"
@@ -14,33 +17,167 @@ type Filter interface {
 type KeywordFilter struct {
 	groups  []config.KeywordGroup
 	filters []string
+
+	// 链接过滤规则，在关键词匹配之后执行
+	urlRuleConfig []config.URLRule
+	urlRules      *URLRules
+
+	// 语义匹配器，为 nil 时只进行字面匹配
+	semantic *SemanticMatcher
+
+	// 以下为加载时预编译的匹配结构，所有关键词共用一个自动机
+	matcher       *Matcher
+	filterIDs     []int32
+	compiled      []compiledGroup
+	patternGroups [][]int // 模式串ID -> 引用该词的关键词组索引
+}
+
+// compiledGroup 关键词组在自动机中的模式串ID
+type compiledGroup struct {
+	required        []int32
+	normal          []int32
+	requiredWeights []float64
+	normalWeights   []float64
 }
 
 func NewKeywordFilter(groups []config.KeywordGroup, filters []string) *KeywordFilter {
-	return &KeywordFilter{
+	f := &KeywordFilter{
 		groups:  groups,
 		filters: filters,
 	}
+	f.compile()
+	return f
+}
+
+// compile 将全部关键词（去重、转小写）构建为一个 Aho-Corasick 自动机
+func (f *KeywordFilter) compile() {
+	var patterns []string
+	ids := make(map[string]int32)
+	idOf := func(word string) int32 {
+		lower := strings.ToLower(word)
+		if id, ok := ids[lower]; ok {
+			return id
+		}
+		id := int32(len(patterns))
+		ids[lower] = id
+		patterns = append(patterns, lower)
+		return id
+	}
+
+	for _, w := range f.filters {
+		f.filterIDs = append(f.filterIDs, idOf(w))
+	}
+
+	f.compiled = make([]compiledGroup, len(f.groups))
+	for i, group := range f.groups {
+		for _, w := range group.Required {
+			f.compiled[i].required = append(f.compiled[i].required, idOf(w))
+			f.compiled[i].requiredWeights = append(f.compiled[i].requiredWeights, wordWeight(group, w))
+		}
+		for _, w := range group.Normal {
+			f.compiled[i].normal = append(f.compiled[i].normal, idOf(w))
+			f.compiled[i].normalWeights = append(f.compiled[i].normalWeights, wordWeight(group, w))
+		}
+	}
+
+	f.patternGroups = make([][]int, len(patterns))
+	for i, c := range f.compiled {
+		for _, list := range [][]int32{c.required, c.normal} {
+			for _, id := range list {
+				refs := f.patternGroups[id]
+				if len(refs) == 0 || refs[len(refs)-1] != i {
+					f.patternGroups[id] = append(refs, i)
+				}
+			}
+		}
+	}
+
+	f.matcher = NewMatcher(patterns)
+}
+
+// wordWeight 返回关键词在组内的权重，未设置时为1
+func wordWeight(group config.KeywordGroup, word string) float64 {
+	if w, ok := group.Weights[word]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// matchState 单次过滤过程中复用的缓冲区
+type matchState struct {
+	hits       []bool
+	ids        []int32
+	candidates []int
+	seen       []bool
+}
+
+func (f *KeywordFilter) newMatchState() *matchState {
+	return &matchState{
+		hits: make([]bool, f.matcher.Len()),
+		seen: make([]bool, len(f.groups)),
+	}
+}
+
+// SetURLRules 设置按域名、路径和来源平台过滤的规则
+func (f *KeywordFilter) SetURLRules(rules []config.URLRule) error {
+	compiled, err := NewURLRules(rules)
+	if err != nil {
+		return err
+	}
+	f.urlRuleConfig = rules
+	f.urlRules = compiled
+	return nil
+}
+
+// SetSemantic 设置语义匹配器，未命中字面关键词的标题再与关键词组描述比较相似度
+func (f *KeywordFilter) SetSemantic(m *SemanticMatcher) {
+	f.semantic = m
+}
+
+// Semantic 返回语义匹配器，未设置时为 nil
+func (f *KeywordFilter) Semantic() *SemanticMatcher {
+	return f.semantic
+}
+
+// SameRules 判断给定的过滤规则是否与当前过滤器一致，用于跨任务复用已构建的自动机
+func (f *KeywordFilter) SameRules(groups []config.KeywordGroup, filters []string, urlRules []config.URLRule, semantic *SemanticMatcher) bool {
+	return reflect.DeepEqual(f.groups, groups) && reflect.DeepEqual(f.filters, filters) &&
+		reflect.DeepEqual(f.urlRuleConfig, urlRules) && f.semantic == semantic
 }
 
 func (f *KeywordFilter) Filter(allData map[string][]*model.NewsItem) (map[string][]*model.NewsItem, error) {
+	return f.FilterContext(context.Background(), allData)
+}
+
+// FilterContext 与 Filter 相同，ctx 用于语义匹配的向量请求
+func (f *KeywordFilter) FilterContext(ctx context.Context, allData map[string][]*model.NewsItem) (map[string][]*model.NewsItem, error) {
 	// 如果没有关键词组，返回空或者全部？原Python代码逻辑：如果没配置，显示全部。
 	// 这里我们假设没配置就返回全部
 	if len(f.groups) == 0 {
-		return allData, nil
+		return f.filterByURL(allData), nil
 	}
 
 	result := make(map[string][]*model.NewsItem)
+	state := f.newMatchState()
+	var pending []*model.NewsItem // 未命中字面关键词、可进行语义匹配的新闻
 
 	for sourceID, items := range allData {
 		var filteredItems []*model.NewsItem
 		for _, item := range items {
-			matched, score, keywords, groupIndex := f.matchWithScore(item.Title)
-			if matched {
+			matched, score, keywords, groupIndex, details := f.matchWithScore(item.Title, state)
+			if !matched {
+				if f.semantic != nil && !f.blocked(state.hits) {
+					pending = append(pending, item)
+				}
+				continue
+			}
+			if f.urlRules.Allow(item, f.groups[groupIndex].Tags) {
 				// 设置匹配信息
 				item.MatchScore = score
 				item.MatchedKeywords = keywords
 				item.KeywordGroup = groupIndex
+				item.KeywordGroupKey = f.groups[groupIndex].GroupKey
+				item.MatchDetails = details
 				filteredItems = append(filteredItems, item)
 			}
 		}
@@ -49,41 +186,151 @@ func (f *KeywordFilter) Filter(allData map[string][]*model.NewsItem) (map[string
 		}
 	}
 
+	for _, item := range f.matchSemantic(ctx, pending) {
+		result[item.SourceID] = append(result[item.SourceID], item)
+	}
+
 	return result, nil
 }
 
+// blocked 判断标题是否命中全局过滤词，hits 为自动机的匹配结果
+func (f *KeywordFilter) blocked(hits []bool) bool {
+	for _, id := range f.filterIDs {
+		if hits[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSemantic 对未命中字面关键词的新闻进行语义匹配，返回匹配成功的新闻
+// 向量服务不可用时返回空，结果等同于只进行字面匹配；错误由 SemanticMatcher 记录，可通过 Unavailable 查询
+func (f *KeywordFilter) matchSemantic(ctx context.Context, items []*model.NewsItem) []*model.NewsItem {
+	if f.semantic == nil || len(items) == 0 {
+		return nil
+	}
+
+	var descriptions []string
+	var descGroups []int
+	for i, group := range f.groups {
+		if group.Description != "" {
+			descriptions = append(descriptions, group.Description)
+			descGroups = append(descGroups, i)
+		}
+	}
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	titles := make([]string, len(items))
+	for i, item := range items {
+		titles[i] = item.Title
+	}
+	best, sims, err := f.semantic.Match(ctx, descriptions, titles)
+	if err != nil {
+		return nil
+	}
+
+	var matched []*model.NewsItem
+	for i, item := range items {
+		if best[i] < 0 {
+			continue
+		}
+		groupIdx := descGroups[best[i]]
+		group := f.groups[groupIdx]
+		if !f.urlRules.Allow(item, group.Tags) {
+			continue
+		}
+
+		// 语义命中按一个普通词计分，乘以相似度和组优先级
+		priority := group.Priority
+		if priority <= 0 {
+			priority = 5
+		}
+		score := 10 * sims[i] * float64(priority) / 5.0
+
+		item.MatchScore = score
+		item.MatchedKeywords = []string{"≈" + group.Description}
+		item.KeywordGroup = groupIdx
+		item.KeywordGroupKey = group.GroupKey
+		item.MatchDetails = []model.KeywordMatch{{Word: group.Description, Weight: sims[i], Points: score, Semantic: true}}
+		matched = append(matched, item)
+	}
+	return matched
+}
+
+// filterByURL 未配置关键词组时只按链接规则过滤
+func (f *KeywordFilter) filterByURL(allData map[string][]*model.NewsItem) map[string][]*model.NewsItem {
+	if f.urlRules.Len() == 0 {
+		return allData
+	}
+
+	result := make(map[string][]*model.NewsItem)
+	for sourceID, items := range allData {
+		var filteredItems []*model.NewsItem
+		for _, item := range items {
+			if f.urlRules.Allow(item, nil) {
+				filteredItems = append(filteredItems, item)
+			}
+		}
+		if len(filteredItems) > 0 {
+			result[sourceID] = filteredItems
+		}
+	}
+	return result
+}
+
 // matchWithScore 匹配标题并返回评分信息
-// 返回值：是否匹配, 匹配分数, 匹配的关键词列表, 关键词组索引
-func (f *KeywordFilter) matchWithScore(title string) (bool, float64, []string, int) {
-	titleLower := strings.ToLower(title)
+// state 为复用的缓冲区
+// 返回值：是否匹配, 匹配分数, 匹配的关键词列表, 关键词组索引, 各关键词得分明细
+func (f *KeywordFilter) matchWithScore(title string, state *matchState) (bool, float64, []string, int, []model.KeywordMatch) {
+	// 一次扫描得到所有关键词的命中情况
+	state.ids = f.matcher.MatchInto(strings.ToLower(title), state.hits, state.ids[:0])
+	hits := state.hits
 
 	// 1. 全局过滤词检查
-	for _, filterWord := range f.filters {
-		if strings.Contains(titleLower, strings.ToLower(filterWord)) {
-			return false, 0, nil, -1
-		}
+	if f.blocked(hits) {
+		return false, 0, nil, -1, nil
 	}
 
 	maxScore := 0.0
 	var bestMatchedKeywords []string
+	var bestDetails []model.KeywordMatch
 	bestGroupIndex := -1
 
-	// 2. 关键词组匹配 - 遍历所有组，找到得分最高的
-	for groupIdx, group := range f.groups {
+	// 2. 关键词组匹配 - 只有包含命中词的组才可能匹配，按组顺序遍历找到得分最高的
+	state.candidates = state.candidates[:0]
+	for _, id := range state.ids {
+		for _, groupIdx := range f.patternGroups[id] {
+			if !state.seen[groupIdx] {
+				state.seen[groupIdx] = true
+				state.candidates = append(state.candidates, groupIdx)
+			}
+		}
+	}
+	sort.Ints(state.candidates)
+	for _, groupIdx := range state.candidates {
+		state.seen[groupIdx] = false
+	}
+
+	for _, groupIdx := range state.candidates {
+		group := f.groups[groupIdx]
+		compiled := f.compiled[groupIdx]
 		score := 0.0
 		var matched []string
+		var details []model.KeywordMatch
 
-		// 检查必须词（每个必须词 +20分）
+		// 检查必须词（每个必须词 +20分 × 权重）
 		allRequiredMatched := true
-		if len(group.Required) > 0 {
-			for _, req := range group.Required {
-				if strings.Contains(titleLower, strings.ToLower(req)) {
-					score += 20
-					matched = append(matched, "+"+req)
-				} else {
-					allRequiredMatched = false
-					break
-				}
+		for i, id := range compiled.required {
+			if hits[id] {
+				weight := compiled.requiredWeights[i]
+				score += 20 * weight
+				matched = append(matched, "+"+group.Required[i])
+				details = append(details, model.KeywordMatch{Word: group.Required[i], Required: true, Weight: weight, Points: 20 * weight})
+			} else {
+				allRequiredMatched = false
+				break
 			}
 		}
 
@@ -91,13 +338,15 @@ func (f *KeywordFilter) matchWithScore(title string) (bool, float64, []string, i
 			continue
 		}
 
-		// 检查普通词（每个普通词 +10分）
+		// 检查普通词（每个普通词 +10分 × 权重）
 		normalMatched := false
-		if len(group.Normal) > 0 {
-			for _, norm := range group.Normal {
-				if strings.Contains(titleLower, strings.ToLower(norm)) {
-					score += 10
-					matched = append(matched, norm)
+		if len(compiled.normal) > 0 {
+			for i, id := range compiled.normal {
+				if hits[id] {
+					weight := compiled.normalWeights[i]
+					score += 10 * weight
+					matched = append(matched, group.Normal[i])
+					details = append(details, model.KeywordMatch{Word: group.Normal[i], Weight: weight, Points: 10 * weight})
 					normalMatched = true
 				}
 			}
@@ -123,15 +372,21 @@ func (f *KeywordFilter) matchWithScore(title string) (bool, float64, []string, i
 		if score > maxScore {
 			maxScore = score
 			bestMatchedKeywords = matched
+			bestDetails = details
 			bestGroupIndex = groupIdx
 		}
 	}
 
 	if maxScore > 0 {
-		return true, maxScore, bestMatchedKeywords, bestGroupIndex
+		priority := f.groups[bestGroupIndex].Priority
+		if priority <= 0 {
+			priority = 5
+		}
+		for i := range bestDetails {
+			bestDetails[i].Points *= float64(priority) / 5.0
+		}
+		return true, maxScore, bestMatchedKeywords, bestGroupIndex, bestDetails
 	}
 
-	return false, 0, nil, -1
+	return false, 0, nil, -1, nil
 }
-
-
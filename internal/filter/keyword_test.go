@@ -0,0 +1,282 @@
+package filter
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// naiveMatch 逐组逐词 strings.Contains 的匹配实现，作为自动机的对照
+func naiveMatch(groups []config.KeywordGroup, filters []string, title string) (bool, float64, []string, int) {
+	titleLower := strings.ToLower(title)
+	for _, w := range filters {
+		if strings.Contains(titleLower, strings.ToLower(w)) {
+			return false, 0, nil, -1
+		}
+	}
+
+	maxScore := 0.0
+	var best []string
+	bestIdx := -1
+	for idx, group := range groups {
+		score := 0.0
+		var matched []string
+		ok := true
+		for _, req := range group.Required {
+			if !strings.Contains(titleLower, strings.ToLower(req)) {
+				ok = false
+				break
+			}
+			score += 20 * wordWeight(group, req)
+			matched = append(matched, "+"+req)
+		}
+		if !ok {
+			continue
+		}
+		normalMatched := len(group.Normal) == 0
+		for _, norm := range group.Normal {
+			if strings.Contains(titleLower, strings.ToLower(norm)) {
+				score += 10 * wordWeight(group, norm)
+				matched = append(matched, norm)
+				normalMatched = true
+			}
+		}
+		if !normalMatched {
+			continue
+		}
+		priority := group.Priority
+		if priority <= 0 {
+			priority = 5
+		}
+		score *= float64(priority) / 5.0
+		if score > maxScore {
+			maxScore = score
+			best = matched
+			bestIdx = idx
+		}
+	}
+	return maxScore > 0, maxScore, best, bestIdx
+}
+
+// checkAgainstNaive 校验 KeywordFilter 与逐词匹配对每条标题的结果一致
+func checkAgainstNaive(t *testing.T, groups []config.KeywordGroup, filters []string, data map[string][]*model.NewsItem) {
+	t.Helper()
+	filtered, err := NewKeywordFilter(groups, filters).Filter(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[*model.NewsItem]bool)
+	for _, items := range filtered {
+		for _, item := range items {
+			got[item] = true
+		}
+	}
+
+	for _, items := range data {
+		for _, item := range items {
+			matched, score, keywords, idx := naiveMatch(groups, filters, item.Title)
+			if matched != got[item] {
+				t.Errorf("%q: naive matched=%v, automaton matched=%v", item.Title, matched, got[item])
+				continue
+			}
+			if !matched {
+				continue
+			}
+			if score != item.MatchScore || idx != item.KeywordGroup || strings.Join(keywords, ",") != strings.Join(item.MatchedKeywords, ",") {
+				t.Errorf("%q: naive=(%v, %d, %v), automaton=(%v, %d, %v)",
+					item.Title, score, idx, keywords, item.MatchScore, item.KeywordGroup, item.MatchedKeywords)
+			}
+		}
+	}
+}
+
+func titlesData(titles ...string) map[string][]*model.NewsItem {
+	data := make(map[string][]*model.NewsItem)
+	for i, title := range titles {
+		data["test"] = append(data["test"], &model.NewsItem{Title: title, SourceID: "test", Ranks: []int{i + 1}})
+	}
+	return data
+}
+
+func TestMatchAgreesWithNaive(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []config.KeywordGroup
+		filters []string
+		titles  []string
+	}{
+		{
+			name:   "normal words",
+			groups: []config.KeywordGroup{{Normal: []string{"华为", "小米"}}},
+			titles: []string{"华为发布新手机", "小米和华为同台", "苹果发布会", ""},
+		},
+		{
+			name:   "required words",
+			groups: []config.KeywordGroup{{Required: []string{"芯片"}, Normal: []string{"华为", "台积电"}}},
+			titles: []string{"华为芯片突破", "华为新手机", "芯片行业动态", "台积电芯片产能"},
+		},
+		{
+			name:   "required words only",
+			groups: []config.KeywordGroup{{Required: []string{"降息", "央行"}}},
+			titles: []string{"央行宣布降息", "央行例行发布会", "美联储降息"},
+		},
+		{
+			name:    "global filters",
+			groups:  []config.KeywordGroup{{Normal: []string{"华为", "比亚迪"}}},
+			filters: []string{"广告", "华为终端"},
+			titles:  []string{"华为新品", "华为终端发布会", "比亚迪广告投放", "比亚迪销量"},
+		},
+		{
+			name: "overlapping words",
+			groups: []config.KeywordGroup{
+				{Normal: []string{"华为", "华为手机", "为手"}},
+				{Required: []string{"手机"}, Normal: []string{"华为手"}},
+			},
+			filters: []string{"机壳"},
+			titles:  []string{"华为手机销量", "华为手表", "华为手机壳", "有为手艺人", "华为华为手机"},
+		},
+		{
+			name:   "case insensitive",
+			groups: []config.KeywordGroup{{Normal: []string{"OpenAI", "gpt"}}},
+			titles: []string{"openai 发布 GPT-5", "OPENAI", "Gpt 模型"},
+		},
+		{
+			name: "best group by score and priority",
+			groups: []config.KeywordGroup{
+				{Normal: []string{"新能源", "汽车"}, Priority: 3},
+				{Normal: []string{"比亚迪"}, Priority: 10},
+				{Normal: []string{"汽车"}, Weights: map[string]float64{"汽车": 3}},
+				{Required: []string{"比亚迪"}, Normal: []string{"汽车"}},
+			},
+			titles: []string{"新能源汽车销量", "比亚迪新能源汽车", "汽车下乡", "比亚迪"},
+		},
+		{
+			name: "shared words across groups",
+			groups: []config.KeywordGroup{
+				{Normal: []string{"茅台", "五粮液"}},
+				{Required: []string{"白酒"}, Normal: []string{"茅台"}},
+				{Normal: []string{"五粮液"}, Priority: 1},
+			},
+			titles: []string{"茅台股价", "白酒板块茅台领涨", "五粮液分红", "白酒消费"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkAgainstNaive(t, tt.groups, tt.filters, titlesData(tt.titles...))
+		})
+	}
+}
+
+func TestMatchAgreesWithNaiveRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	groups, terms := benchGroups(r, 2000, 20)
+	checkAgainstNaive(t, groups, terms[:5], benchTitles(r, terms, 1000))
+}
+
+// 用于生成模拟关键词（股票名、公司名等）的字符表
+const benchCharset = "华为腾讯阿里巴百度字节跳动小米比亚迪宁德时代美团京东拼多多网易快手理想蔚来零跑长城吉利海尔格力茅台五粮液招商平安中信证券银行保险科技电子能源汽车医药芯片半导体光伏材料智能云计算数据"
+
+// benchGroups 生成关键词组，每组包含 groupSize 个普通词，每 5 组有一组带必须词
+func benchGroups(r *rand.Rand, termCount, groupSize int) ([]config.KeywordGroup, []string) {
+	chars := []rune(benchCharset)
+	seen := make(map[string]bool)
+	var terms []string
+	for len(terms) < termCount {
+		n := 2 + r.Intn(4)
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteRune(chars[r.Intn(len(chars))])
+		}
+		term := sb.String()
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	var groups []config.KeywordGroup
+	for i := 0; i < len(terms); i += groupSize {
+		end := i + groupSize
+		if end > len(terms) {
+			end = len(terms)
+		}
+		group := config.KeywordGroup{
+			Normal:   terms[i:end],
+			GroupKey: strings.Join(terms[i:end], " "),
+			Priority: 1 + r.Intn(10),
+		}
+		if len(groups)%5 == 0 {
+			group.Required = []string{string(chars[r.Intn(len(chars))])}
+		}
+		groups = append(groups, group)
+	}
+	return groups, terms
+}
+
+// benchTitles 生成模拟标题，约三分之一包含某个关键词
+func benchTitles(r *rand.Rand, terms []string, count int) map[string][]*model.NewsItem {
+	chars := []rune(benchCharset)
+	data := make(map[string][]*model.NewsItem)
+	for i := 0; i < count; i++ {
+		var sb strings.Builder
+		for j := 0; j < 12+r.Intn(20); j++ {
+			sb.WriteRune(chars[r.Intn(len(chars))])
+		}
+		if r.Intn(3) == 0 {
+			sb.WriteString(terms[r.Intn(len(terms))])
+		}
+		source := fmt.Sprintf("platform-%d", i%10)
+		data[source] = append(data[source], &model.NewsItem{
+			Title:    sb.String(),
+			SourceID: source,
+			Ranks:    []int{len(data[source]) + 1},
+		})
+	}
+	return data
+}
+
+// 基准测试使用 5000 个关键词（每组 20 个）和 500 条标题，对应加载大词表（股票名、公司名等）的场景
+func benchData() ([]config.KeywordGroup, map[string][]*model.NewsItem) {
+	r := rand.New(rand.NewSource(42))
+	groups, terms := benchGroups(r, 5000, 20)
+	return groups, benchTitles(r, terms, 500)
+}
+
+// BenchmarkMatchNaive 逐组逐词 strings.Contains 过滤一轮标题
+func BenchmarkMatchNaive(b *testing.B) {
+	groups, data := benchData()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, items := range data {
+			for _, item := range items {
+				naiveMatch(groups, nil, item.Title)
+			}
+		}
+	}
+}
+
+// BenchmarkMatchAhoCorasick 用预先构建的自动机过滤一轮标题
+func BenchmarkMatchAhoCorasick(b *testing.B) {
+	groups, data := benchData()
+	kf := NewKeywordFilter(groups, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kf.Filter(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMatchBuild 构建自动机，关键词或配置变更时执行一次
+func BenchmarkMatchBuild(b *testing.B) {
+	groups, _ := benchData()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewKeywordFilter(groups, nil)
+	}
+}
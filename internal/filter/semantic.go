@@ -0,0 +1,193 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/embedding"
+	"github.com/gotoailab/trendhub/internal/logger"
+)
+
+const (
+	// DefaultSemanticThreshold 默认余弦相似度阈值
+	DefaultSemanticThreshold = 0.75
+	// DefaultSemanticTimeout 默认请求超时（秒）
+	DefaultSemanticTimeout = 10
+
+	semanticBatchSize     = 64              // 单次请求的文本数量
+	semanticCacheSize     = 20000           // 向量缓存条数上限，超出后清空重建
+	semanticRetryInterval = 5 * time.Minute // 服务不可用后暂停语义匹配的时长
+)
+
+// SemanticMatcher 基于文本向量的语义匹配器
+// 标题和关键词组描述的向量会被缓存，热榜标题在多次抓取间大量重复，缓存可显著减少请求
+type SemanticMatcher struct {
+	cfg      config.SemanticConfig
+	provider embedding.Provider
+
+	mu            sync.Mutex
+	cache         map[string][]float32 // 文本 -> 归一化后的向量
+	disabledUntil time.Time            // 服务不可用时，在此之前直接跳过语义匹配
+	lastErr       error                // 导致暂停语义匹配的错误
+}
+
+// NewSemanticMatcher 根据配置创建语义匹配器
+func NewSemanticMatcher(cfg config.SemanticConfig) (*SemanticMatcher, error) {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultSemanticThreshold
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSemanticTimeout
+	}
+	provider, err := embedding.NewProvider(cfg.Provider, cfg.BaseURL, cfg.APIKey, cfg.Model, time.Duration(cfg.Timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &SemanticMatcher{
+		cfg:      cfg,
+		provider: provider,
+		cache:    make(map[string][]float32),
+	}, nil
+}
+
+// Match 为每个标题找出相似度最高且超过阈值的描述
+// 返回描述索引（未匹配为 -1）和相似度；服务不可用时返回错误，调用方应退回字面匹配
+// 服务出错后暂停语义匹配 5 分钟，错误只在暂停开始时记录一次日志；ctx 被取消时不暂停
+func (m *SemanticMatcher) Match(ctx context.Context, descriptions, titles []string) ([]int, []float64, error) {
+	best := make([]int, len(titles))
+	sims := make([]float64, len(titles))
+	for i := range best {
+		best[i] = -1
+	}
+	if len(descriptions) == 0 || len(titles) == 0 {
+		return best, sims, nil
+	}
+
+	m.mu.Lock()
+	disabledUntil := m.disabledUntil
+	m.mu.Unlock()
+	if time.Now().Before(disabledUntil) {
+		return best, sims, fmt.Errorf("embedding provider unavailable, retry after %s", disabledUntil.Format("15:04:05"))
+	}
+
+	descVectors, err := m.vectors(ctx, descriptions)
+	if err == nil {
+		var titleVectors [][]float32
+		titleVectors, err = m.vectors(ctx, titles)
+		if err == nil {
+			for i, tv := range titleVectors {
+				for j, dv := range descVectors {
+					sim := dot(tv, dv)
+					if sim >= m.cfg.Threshold && sim > sims[i] {
+						best[i] = j
+						sims[i] = sim
+					}
+				}
+			}
+			return best, sims, nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return best, sims, err // 任务超时或取消，不是服务的问题
+	}
+	until := time.Now().Add(semanticRetryInterval)
+	m.mu.Lock()
+	m.disabledUntil = until
+	m.lastErr = err
+	m.mu.Unlock()
+	logger.Errorf("Semantic matching unavailable (%s), falling back to keyword matching until %s: %v",
+		m.provider.Name(), until.Format("15:04:05"), err)
+	return best, sims, err
+}
+
+// Unavailable 语义匹配暂停时返回恢复时间和导致暂停的错误，否则 error 为 nil
+func (m *SemanticMatcher) Unavailable() (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Now().Before(m.disabledUntil) {
+		return m.disabledUntil, m.lastErr
+	}
+	return time.Time{}, nil
+}
+
+// vectors 返回文本的归一化向量，未缓存的文本分批请求
+func (m *SemanticMatcher) vectors(ctx context.Context, texts []string) ([][]float32, error) {
+	result := make([][]float32, len(texts))
+	var missing []string
+	missingIdx := make(map[string][]int)
+
+	m.mu.Lock()
+	for i, text := range texts {
+		if v, ok := m.cache[text]; ok {
+			result[i] = v
+			continue
+		}
+		if _, ok := missingIdx[text]; !ok {
+			missing = append(missing, text)
+		}
+		missingIdx[text] = append(missingIdx[text], i)
+	}
+	m.mu.Unlock()
+
+	for start := 0; start < len(missing); start += semanticBatchSize {
+		end := start + semanticBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		batch := missing[start:end]
+		vectors, err := m.provider.Embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(vectors))
+		}
+
+		m.mu.Lock()
+		if len(m.cache)+len(batch) > semanticCacheSize {
+			m.cache = make(map[string][]float32)
+		}
+		for i, text := range batch {
+			v := normalize(vectors[i])
+			m.cache[text] = v
+			for _, idx := range missingIdx[text] {
+				result[idx] = v
+			}
+		}
+		m.mu.Unlock()
+	}
+	return result, nil
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// dot 归一化向量的点积即余弦相似度
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
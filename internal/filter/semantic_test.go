@@ -0,0 +1,180 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// semanticVectors 测试用的文本向量，“国产手机”与“小米新机开售”相似度约 0.99，与“天气晴朗”约 0.71
+var semanticVectors = map[string][]float32{
+	"国产手机":   {1, 0},
+	"白酒":     {0, 1},
+	"小米新机开售": {0.9, 0.1},
+	"天气晴朗":   {0.6, 0.6},
+}
+
+// embeddingServer 模拟 OpenAI 兼容的 /embeddings 接口，down 为 true 时返回 503
+func embeddingServer(t *testing.T, down *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		type datum struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var resp struct {
+			Data []datum `json:"data"`
+		}
+		for i, text := range req.Input {
+			v, ok := semanticVectors[text]
+			if !ok {
+				t.Errorf("unexpected input %q", text)
+				v = []float32{0, 0}
+			}
+			resp.Data = append(resp.Data, datum{Index: i, Embedding: v})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func semanticFilter(t *testing.T, baseURL string) (*KeywordFilter, *SemanticMatcher) {
+	t.Helper()
+	m, err := NewSemanticMatcher(config.SemanticConfig{Enabled: true, BaseURL: baseURL, Model: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewKeywordFilter([]config.KeywordGroup{
+		{Normal: []string{"华为"}, Description: "国产手机", GroupKey: "华为"},
+		{Normal: []string{"茅台"}, Description: "白酒", GroupKey: "茅台"},
+	}, nil)
+	f.SetSemantic(m)
+	return f, m
+}
+
+// filterTitles 过滤一组新标题，返回匹配的新闻（按标题索引）
+func filterTitles(t *testing.T, ctx context.Context, f *KeywordFilter) map[string]*model.NewsItem {
+	t.Helper()
+	result, err := f.FilterContext(ctx, titlesData("华为发布会", "小米新机开售", "天气晴朗"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := make(map[string]*model.NewsItem)
+	for _, items := range result {
+		for _, item := range items {
+			matched[item.Title] = item
+		}
+	}
+	return matched
+}
+
+func TestSemanticMatch(t *testing.T) {
+	var down atomic.Bool
+	server, requests := embeddingServer(t, &down)
+	f, _ := semanticFilter(t, server.URL)
+
+	matched := filterTitles(t, context.Background(), f)
+	if len(matched) != 2 || matched["华为发布会"] == nil {
+		t.Fatalf("matched %v, want the literal hit and one semantic hit", matched)
+	}
+	item := matched["小米新机开售"]
+	if item == nil {
+		t.Fatal("title above the threshold not matched")
+	}
+	if item.KeywordGroup != 0 || item.KeywordGroupKey != "华为" || len(item.MatchDetails) != 1 || !item.MatchDetails[0].Semantic {
+		t.Errorf("unexpected semantic match %+v", item)
+	}
+	if sim := item.MatchDetails[0].Weight; sim < DefaultSemanticThreshold || sim > 1 {
+		t.Errorf("similarity %.3f out of range", sim)
+	}
+	if want := 10 * item.MatchDetails[0].Weight; item.MatchScore != want {
+		t.Errorf("score = %.3f, want %.3f", item.MatchScore, want)
+	}
+
+	// 向量已缓存，再次过滤不再请求
+	n := requests.Load()
+	filterTitles(t, context.Background(), f)
+	if got := requests.Load(); got != n {
+		t.Errorf("cached titles requested again: %d -> %d requests", n, got)
+	}
+}
+
+func TestSemanticFallback(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server, requests := embeddingServer(t, &down)
+	f, m := semanticFilter(t, server.URL)
+
+	// 服务不可用时只保留字面匹配
+	matched := filterTitles(t, context.Background(), f)
+	if len(matched) != 1 || matched["华为发布会"] == nil {
+		t.Fatalf("matched %v, want only the literal hit", matched)
+	}
+	until, err := m.Unavailable()
+	if err == nil {
+		t.Fatal("Unavailable should report the provider error")
+	}
+	if d := time.Until(until); d < semanticRetryInterval-time.Minute || d > semanticRetryInterval {
+		t.Errorf("paused for %v, want about %v", d, semanticRetryInterval)
+	}
+
+	// 暂停期间不再请求服务
+	down.Store(false)
+	n := requests.Load()
+	if matched := filterTitles(t, context.Background(), f); len(matched) != 1 {
+		t.Errorf("matched %v during backoff, want only the literal hit", matched)
+	}
+	if got := requests.Load(); got != n {
+		t.Errorf("provider requested during backoff: %d -> %d requests", n, got)
+	}
+
+	// 暂停结束后恢复语义匹配
+	m.mu.Lock()
+	m.disabledUntil = time.Now().Add(-time.Second)
+	m.mu.Unlock()
+	if _, err := m.Unavailable(); err != nil {
+		t.Errorf("Unavailable after backoff = %v", err)
+	}
+	if matched := filterTitles(t, context.Background(), f); matched["小米新机开售"] == nil {
+		t.Errorf("semantic matching not resumed after backoff: %v", matched)
+	}
+}
+
+func TestSemanticCanceledContext(t *testing.T) {
+	var down atomic.Bool
+	server, _ := embeddingServer(t, &down)
+	f, m := semanticFilter(t, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if matched := filterTitles(t, ctx, f); len(matched) != 1 {
+		t.Errorf("matched %v with a canceled context, want only the literal hit", matched)
+	}
+	// 任务取消不是服务的问题，不暂停语义匹配
+	if _, err := m.Unavailable(); err != nil {
+		t.Errorf("canceled context paused semantic matching: %v", err)
+	}
+	if matched := filterTitles(t, context.Background(), f); matched["小米新机开售"] == nil {
+		t.Errorf("semantic matching not available after a canceled task: %v", matched)
+	}
+}
@@ -0,0 +1,168 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// URLRules 按链接域名、路径和来源平台过滤新闻的规则集
+// 规则按配置顺序匹配，第一条命中的规则决定保留或排除，均未命中时保留
+type URLRules struct {
+	rules []urlRule
+}
+
+type urlRule struct {
+	include    bool
+	hosts      []string
+	paths      []string
+	platforms  map[string]bool
+	unlessTags []string
+}
+
+// NewURLRules 校验并编译规则
+func NewURLRules(rules []config.URLRule) (*URLRules, error) {
+	r := &URLRules{}
+	for i, rule := range rules {
+		var compiled urlRule
+		switch strings.ToLower(strings.TrimSpace(rule.Action)) {
+		case "include":
+			compiled.include = true
+		case "exclude", "":
+			compiled.include = false
+		default:
+			return nil, fmt.Errorf("url rule %d: unknown action %q", i+1, rule.Action)
+		}
+
+		for _, h := range rule.Hosts {
+			h = strings.ToLower(strings.TrimSpace(h))
+			h = strings.TrimPrefix(strings.TrimPrefix(h, "*."), ".")
+			if h != "" {
+				compiled.hosts = append(compiled.hosts, h)
+			}
+		}
+		for _, p := range rule.Paths {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if _, err := path.Match(strings.TrimSuffix(p, "/**"), "/"); err != nil {
+				return nil, fmt.Errorf("url rule %d: invalid path pattern %q: %w", i+1, p, err)
+			}
+			compiled.paths = append(compiled.paths, p)
+		}
+		if len(rule.Platforms) > 0 {
+			compiled.platforms = make(map[string]bool, len(rule.Platforms))
+			for _, p := range rule.Platforms {
+				compiled.platforms[strings.TrimSpace(p)] = true
+			}
+		}
+		compiled.unlessTags = rule.UnlessTags
+
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+// Len 返回规则数量
+func (r *URLRules) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.rules)
+}
+
+// Allow 判断新闻是否保留，tags 为该新闻匹配的关键词组标签
+func (r *URLRules) Allow(item *model.NewsItem, tags []string) bool {
+	if r.Len() == 0 {
+		return true
+	}
+
+	host, urlPath := splitURL(item.URL)
+	if host == "" {
+		host, urlPath = splitURL(item.MobileURL)
+	}
+
+	for _, rule := range r.rules {
+		if !rule.matches(item.SourceID, host, urlPath) {
+			continue
+		}
+		if hasAnyTag(tags, rule.unlessTags) {
+			continue
+		}
+		return rule.include
+	}
+	return true
+}
+
+// matches 所有已配置的条件都满足时规则命中；未配置任何条件的规则匹配全部新闻
+func (rule *urlRule) matches(platform, host, urlPath string) bool {
+	if rule.platforms != nil && !rule.platforms[platform] {
+		return false
+	}
+	if len(rule.hosts) > 0 && !matchHost(host, rule.hosts) {
+		return false
+	}
+	if len(rule.paths) > 0 && !matchPath(urlPath, rule.paths) {
+		return false
+	}
+	return true
+}
+
+// matchHost 域名匹配自身及其子域名
+func matchHost(host string, hosts []string) bool {
+	if host == "" {
+		return false
+	}
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 路径按 path.Match 通配匹配，以 /** 结尾的模式匹配该前缀下的所有路径
+func matchPath(urlPath string, patterns []string) bool {
+	if urlPath == "" {
+		urlPath = "/"
+	}
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "/**"); ok {
+			if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func splitURL(raw string) (string, string) {
+	if raw == "" {
+		return "", ""
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", ""
+	}
+	return strings.ToLower(u.Hostname()), u.Path
+}
+
+func hasAnyTag(tags, want []string) bool {
+	for _, w := range want {
+		for _, t := range tags {
+			if strings.EqualFold(t, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package model
+
+// 推送渠道的发送结果
+const (
+	DeliverySuccess = "success"
+	DeliveryFailed  = "failed"
+)
+
+// Delivery 一个推送渠道的发送结果
+type Delivery struct {
+	Channel   string `json:"channel"`              // 通知器名称，如 Feishu、Telegram
+	ChannelID string `json:"channel_id,omitempty"` // 渠道标识，如 feishu、webhook:gotify，重试时据此找到同一个渠道
+	Status    string `json:"status"`               // success 或 failed
+	Batches   int    `json:"batches"`              // 成功发送的消息条数，分批发送时为批数
+	Duration  int64  `json:"duration"`             // 发送耗时（毫秒），包含分批之间的等待
+	Error     string `json:"error,omitempty"`      // 失败原因
+}
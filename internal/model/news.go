@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // NewsItem 代表一条新闻数据
 type NewsItem struct {
 	Title           string   `json:"title"`
@@ -15,6 +17,54 @@ type NewsItem struct {
 	MatchScore      float64  `json:"match_score"`      // 关键词匹配分数
 	MatchedKeywords []string `json:"matched_keywords"` // 匹配到的关键词列表
 	KeywordGroup    int      `json:"keyword_group"`    // 匹配的关键词组索引
+
+	KeywordGroupKey string `json:"keyword_group_key,omitempty"` // 匹配的关键词组标识，调整关键词文件中组的顺序后仍然稳定
+
+	BoardSize int `json:"board_size,omitempty"` // 抓取时平台榜单的条数，用于按榜单长度归一化排名
+
+	FirstSeenAt time.Time `json:"first_seen_at"` // 首次发现的完整时间，由数据缓存跨抓取记录
+	LastSeenAt  time.Time `json:"last_seen_at"`  // 最后一次发现的完整时间
+
+	MatchDetails []KeywordMatch  `json:"match_details,omitempty"` // 各关键词对匹配分数的贡献
+	Score        *ScoreBreakdown `json:"score,omitempty"`         // 排序得分明细，排序后填充
+}
+
+// ScoreBreakdown 排序得分明细，用于解释排序结果
+// 各分项为已乘以对应权重后的贡献值，Base 为各分项之和
+type ScoreBreakdown struct {
+	Strategy           string  `json:"strategy"`            // 排序策略
+	Position           int     `json:"position"`            // 排序后的名次，从1开始
+	Rank               float64 `json:"rank"`                // 平台排名贡献
+	Frequency          float64 `json:"frequency"`           // 出现频次贡献
+	Hotness            float64 `json:"hotness"`             // 热度贡献
+	Keyword            float64 `json:"keyword"`             // 关键词匹配贡献
+	Freshness          float64 `json:"freshness"`           // 时效性贡献
+	Consensus          float64 `json:"consensus"`           // 跨平台共识贡献
+	Base               float64 `json:"base"`                // 加权总分（应用平台系数之前）
+	PlatformMultiplier float64 `json:"platform_multiplier"` // 平台权重系数
+	Weighted           float64 `json:"weighted"`            // 加权总分 × 平台权重系数
+
+	ConsensusPlatforms []string `json:"consensus_platforms,omitempty"` // 同一事件上榜的平台（含自身）
+
+	// 以下为各策略特有的中间值
+	Decay    float64 `json:"decay,omitempty"`    // recency-decay 的衰减系数
+	Velocity float64 `json:"velocity,omitempty"` // velocity-first 的上升速度
+	Priority int     `json:"priority,omitempty"` // keyword-priority-first 的关键词组优先级
+	Fusion   float64 `json:"fusion,omitempty"`   // reciprocal-rank-fusion 的融合分
+	Feedback float64 `json:"feedback,omitempty"` // feedback 的反馈调整系数
+
+	Total float64 `json:"total"` // 策略的主排序分
+
+	OriginalPosition int `json:"original_position,omitempty"` // 多样性重排前的名次，未重排时为0
+}
+
+// KeywordMatch 单个命中关键词的得分明细
+type KeywordMatch struct {
+	Word     string  `json:"word"`
+	Required bool    `json:"required"`           // 是否为必须词
+	Weight   float64 `json:"weight"`             // 关键词权重，默认1
+	Points   float64 `json:"points"`             // 计入权重和组优先级后的得分
+	Semantic bool    `json:"semantic,omitempty"` // 是否为语义匹配，此时 Word 为组描述、Weight 为相似度
 }
 
 // Platform 代表一个监控平台
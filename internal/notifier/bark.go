@@ -8,12 +8,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
 type BarkNotifier struct {
 	serverURL string
 	deviceKey string
+	tmpl      *MessageTemplate
 }
 
 // NewBarkNotifier 创建 Bark 推送通知器
@@ -30,9 +32,19 @@ func NewBarkNotifier(serverURL, deviceKey string) *BarkNotifier {
 	return &BarkNotifier{
 		serverURL: serverURL,
 		deviceKey: deviceKey,
+		tmpl:      defaultMessageTemplate("bark"),
 	}
 }
 
+func (n *BarkNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("bark", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
 func (n *BarkNotifier) Name() string {
 	return "Bark"
 }
@@ -42,31 +54,20 @@ func (n *BarkNotifier) Send(ctx context.Context, items []*model.NewsItem) error
 		return fmt.Errorf("bark device key is empty")
 	}
 
-	// 构建消息内容
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("热点监控 (%s)\n\n", time.Now().Format("15:04")))
-
-	// 最多显示前 10 条
+	// 最多显示前 10 条，其余条数通过 footer 模板中的 .Omitted 显示
 	maxItems := 10
+	omitted := 0
 	if len(items) > maxItems {
+		omitted = len(items) - maxItems
 		items = items[:maxItems]
 	}
 
-	currentSource := ""
-	for _, item := range items {
-		if item.SourceName != currentSource {
-			sb.WriteString(fmt.Sprintf("\n【%s】\n", item.SourceName))
-			currentSource = item.SourceName
-		}
-		sb.WriteString(fmt.Sprintf("%d. %s\n", item.Ranks[0], item.Title))
-	}
-
-	if len(items) >= maxItems {
-		sb.WriteString(fmt.Sprintf("\n...还有 %d 条", len(items)-maxItems))
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items, Omitted: omitted})
+	if err != nil {
+		return err
 	}
-
-	title := "TrendHub 热点监控"
-	body := sb.String()
+	title := content.Title
+	body := content.text()
 
 	// 构建 URL
 	// Bark API: GET {serverURL}/{deviceKey}/{title}/{body}?url={url}&group={group}&sound={sound}
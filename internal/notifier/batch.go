@@ -0,0 +1,204 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 未配置分批大小时使用的默认值（字节）
+const (
+	DefaultMessageBatchSize  = 4000
+	DefaultDingtalkBatchSize = 20000
+	DefaultFeishuBatchSize   = 28000
+)
+
+// batchContinuedSuffix 分组被拆到下一批时，下一批开头重复的分组标题后附加的后缀
+const batchContinuedSuffix = "（续）"
+
+// BatchOptions 分批发送的参数，对应配置中的 message_batch_size、batch_send_interval 等
+type BatchOptions struct {
+	Size      int           // 每批消息的字节数上限，0 表示使用 DefaultMessageBatchSize
+	Interval  time.Duration // 两批之间的发送间隔
+	Separator string        // 分组之间的分隔行，为空时空一行
+}
+
+// limit 返回每批的字节数上限，不超过平台的限制 max（0 表示没有限制）
+func (o BatchOptions) limit(max int) int {
+	size := o.Size
+	if size <= 0 {
+		size = DefaultMessageBatchSize
+	}
+	if max > 0 && size > max {
+		size = max
+	}
+	return size
+}
+
+// batching 嵌入到分批发送的通知器中，由 NotificationManager 按配置设置分批参数
+type batching struct {
+	batch BatchOptions
+}
+
+func (b *batching) setBatchOptions(opts BatchOptions) {
+	b.batch = opts
+}
+
+// batchCounterKey context 中记录已发送批数的键
+type batchCounterKey struct{}
+
+// withBatchCounter 返回记录 sendBatches 成功发送批数的 context，用于统计各渠道的发送结果
+func withBatchCounter(ctx context.Context) (context.Context, *int) {
+	sent := new(int)
+	return context.WithValue(ctx, batchCounterKey{}, sent), sent
+}
+
+// batchSkipKey context 中记录要跳过的批数的键
+type batchSkipKey struct{}
+
+// withBatchSkip 返回让 sendBatches 跳过前 skip 批的 context，用于重试时不再重复发送已成功的批次
+func withBatchSkip(ctx context.Context, skip int) context.Context {
+	return context.WithValue(ctx, batchSkipKey{}, skip)
+}
+
+// sendBatches 依次发送 n 批消息，两批之间等待配置的间隔；出错时停止发送，错误中带有批次序号
+func (b *batching) sendBatches(ctx context.Context, n int, send func(i int) error) error {
+	sent, _ := ctx.Value(batchCounterKey{}).(*int)
+	skip, _ := ctx.Value(batchSkipKey{}).(int)
+	for i := skip; i < n; i++ {
+		if i > skip && b.batch.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("batch %d/%d: %w", i+1, n, ctx.Err())
+			case <-time.After(b.batch.Interval):
+			}
+		}
+		if err := send(i); err != nil {
+			if n > 1 {
+				return fmt.Errorf("batch %d/%d: %w", i+1, n, err)
+			}
+			return err
+		}
+		if sent != nil {
+			*sent++
+		}
+	}
+	return nil
+}
+
+// batchedNotifier 分批发送的通知器
+type batchedNotifier interface {
+	setBatchOptions(opts BatchOptions)
+}
+
+// pageMarker 返回第 i 批（从0开始）的页码，如 " (1/3)"，只有一批时为空
+func pageMarker(i, n int) string {
+	if n <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d/%d)", i+1, n)
+}
+
+// messageBatch 一批消息
+type messageBatch struct {
+	Header string // 带页码的 header
+	Body   string // 分组和新闻，不含 header
+}
+
+// text 返回 header 和正文组成的完整消息
+func (b messageBatch) text() string {
+	if b.Header == "" {
+		return b.Body
+	}
+	if b.Body == "" {
+		return b.Header
+	}
+	return b.Header + "\n\n" + b.Body
+}
+
+// batches 按字节数上限把消息拆成多批，只在新闻之间拆分
+// 每批都带有 header 和页码；分组被拆开时，下一批开头重复分组标题并附加“（续）”；footer 放在最后一批
+func (m *renderedMessage) batches(limit int, separator string) []messageBatch {
+	marker := pageMarker
+	if m.escape != nil {
+		// 页码中的括号在 MarkdownV2 等格式中需要转义
+		marker = func(i, n int) string { return m.escape(pageMarker(i, n)) }
+	}
+	budget := limit
+	if m.Header != "" {
+		budget -= len(m.Header) + len(marker(998, 999)) + len("\n\n")
+	}
+	if budget < 1 {
+		budget = 1
+	}
+	gap := "\n"
+	if separator != "" {
+		gap = separator + "\n"
+	}
+
+	var bodies []string
+	var sb strings.Builder
+	flush := func() {
+		if sb.Len() > 0 {
+			bodies = append(bodies, strings.TrimRight(sb.String(), "\n"))
+			sb.Reset()
+		}
+	}
+	// write 写入一段内容，piece 根据当前批是否为空生成，超过上限时先开始新的一批
+	write := func(piece func(empty bool) string) {
+		s := piece(sb.Len() == 0)
+		if sb.Len() > 0 && sb.Len()+len(s) > budget {
+			flush()
+			s = piece(true)
+		}
+		if len(s) <= budget {
+			sb.WriteString(s)
+			return
+		}
+		// 单条新闻超过上限，按字符截断
+		for _, chunk := range chunkLines([]string{s}, budget) {
+			flush()
+			sb.WriteString(chunk)
+		}
+	}
+
+	for _, group := range m.Groups {
+		for i, item := range group.Items {
+			write(func(empty bool) string {
+				prefix := ""
+				switch {
+				case group.Header == "":
+				case i == 0 && empty:
+					prefix = group.Header + "\n"
+				case i == 0:
+					prefix = gap + group.Header + "\n"
+				case empty:
+					prefix = group.Header + batchContinuedSuffix + "\n"
+				}
+				return prefix + item + "\n"
+			})
+		}
+	}
+	if m.Footer != "" {
+		write(func(empty bool) string {
+			if empty {
+				return m.Footer + "\n"
+			}
+			return "\n" + m.Footer + "\n"
+		})
+	}
+	flush()
+	if len(bodies) == 0 {
+		bodies = []string{""}
+	}
+
+	batches := make([]messageBatch, len(bodies))
+	for i, body := range bodies {
+		batches[i] = messageBatch{Body: body}
+		if m.Header != "" {
+			batches[i].Header = m.Header + marker(i, len(bodies))
+		}
+	}
+	return batches
+}
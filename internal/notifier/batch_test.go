@@ -0,0 +1,231 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// captureServer 记录收到的 JSON 请求体，响应钉钉和飞书的成功结果
+func captureServer(t *testing.T) (*httptest.Server, func() []map[string]any) {
+	t.Helper()
+	var mu sync.Mutex
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.Write([]byte(`{"errcode":0,"code":0}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies
+	}
+}
+
+// longNews 生成 n 条标题较长的新闻，都属于关键词组 group
+func longNews(n int, group string) []*model.NewsItem {
+	items := make([]*model.NewsItem, n)
+	for i := range items {
+		items[i] = &model.NewsItem{
+			Title:           fmt.Sprintf("第%d条%s", i+1, strings.Repeat("很长的新闻标题", 10)),
+			URL:             fmt.Sprintf("https://example.com/news/%d", i+1),
+			SourceID:        "weibo",
+			SourceName:      "微博",
+			Ranks:           []int{i + 1},
+			KeywordGroupKey: group,
+		}
+	}
+	return items
+}
+
+func TestDingtalkMentionFitsBatch(t *testing.T) {
+	server, bodies := captureServer(t)
+	n := NewDingtalkNotifier(server.URL, DingtalkOptions{
+		AtMobiles:     []string{"13800000000", "13900000000"},
+		GroupPriority: map[string]int{strings.Repeat("重要关键词", 20): 9},
+	})
+	const size = 2000
+	n.setBatchOptions(BatchOptions{Size: size})
+
+	if err := n.Send(context.Background(), longNews(40, strings.Repeat("重要关键词", 20))); err != nil {
+		t.Fatal(err)
+	}
+	got := bodies()
+	if len(got) < 2 {
+		t.Fatalf("got %d messages, want the digest split into batches", len(got))
+	}
+	for i, body := range got {
+		text := body["markdown"].(map[string]any)["text"].(string)
+		if len(text) > size {
+			t.Errorf("message %d is %d bytes, over the %d byte batch size", i+1, len(text), size)
+		}
+		if mentioned := strings.Contains(text, "@13800000000"); mentioned != (i == 0) {
+			t.Errorf("message %d: mention present = %v", i+1, mentioned)
+		}
+	}
+	if _, ok := got[0]["at"]; !ok {
+		t.Error("first message has no at field")
+	}
+}
+
+func TestPlatformByteCaps(t *testing.T) {
+	items := longNews(400, "")
+
+	t.Run("dingtalk", func(t *testing.T) {
+		server, bodies := captureServer(t)
+		n := NewDingtalkNotifier(server.URL, DingtalkOptions{MsgType: DingtalkMsgTypeActionCard, PublicURL: "https://trendhub.example.com"})
+		n.setBatchOptions(BatchOptions{Size: 100000})
+		if err := n.Send(context.Background(), items); err != nil {
+			t.Fatal(err)
+		}
+		for i, body := range bodies() {
+			if text := body["actionCard"].(map[string]any)["text"].(string); len(text) > DingtalkMessageLimit {
+				t.Errorf("message %d is %d bytes, over DingtalkMessageLimit", i+1, len(text))
+			}
+		}
+	})
+
+	t.Run("feishu", func(t *testing.T) {
+		server, bodies := captureServer(t)
+		n := NewFeishuNotifier(server.URL, FeishuMsgTypeText, "")
+		n.setBatchOptions(BatchOptions{Size: 100000})
+		if err := n.Send(context.Background(), items); err != nil {
+			t.Fatal(err)
+		}
+		got := bodies()
+		if len(got) < 2 {
+			t.Fatalf("got %d messages, want the digest split by FeishuTextLimit", len(got))
+		}
+		for i, body := range got {
+			if text := body["content"].(map[string]any)["text"].(string); len(text) > FeishuTextLimit {
+				t.Errorf("message %d is %d bytes, over FeishuTextLimit", i+1, len(text))
+			}
+		}
+	})
+}
+
+func TestSlackHeaderTruncated(t *testing.T) {
+	server, bodies := captureServer(t)
+	n := NewSlackNotifier(server.URL)
+	title := strings.Repeat("很长的标题", 40)
+	if err := n.setTemplates(config.TemplatesConfig{Channels: map[string]map[string]string{
+		"slack": {"header": title},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(context.Background(), longNews(3, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	body := bodies()[0]
+	header := body["blocks"].([]any)[0].(map[string]any)
+	text := header["text"].(map[string]any)["text"].(string)
+	if header["type"] != "header" || utf8.RuneCountInString(text) != SlackHeaderText || !strings.HasSuffix(text, "…") {
+		t.Errorf("header block = %q (%d chars), want %d chars ending with …", text, utf8.RuneCountInString(text), SlackHeaderText)
+	}
+	if body["text"] != title {
+		t.Errorf("notification text should keep the full title, got %q", body["text"])
+	}
+}
+
+func TestDiscordLimits(t *testing.T) {
+	server, bodies := captureServer(t)
+	n := NewDiscordNotifier(server.URL)
+	n.setBatchOptions(BatchOptions{Size: 100000})
+	if err := n.setTemplates(config.TemplatesConfig{Channels: map[string]map[string]string{
+		"discord": {"header": strings.Repeat("很长的标题", 500), "group": strings.Repeat("很长的分组", 60)},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(context.Background(), longNews(200, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := bodies()
+	if len(got) < 2 {
+		t.Fatalf("got %d messages, want the digest split across messages", len(got))
+	}
+	continued := false
+	for i, body := range got {
+		// 截断标题时保留页码
+		content := body["content"].(string)
+		if utf8.RuneCountInString(content) != DiscordContent || !strings.HasSuffix(content, pageMarker(i, len(got))) {
+			t.Errorf("message %d content is %d chars, want %d chars ending with the page marker", i+1, utf8.RuneCountInString(content), DiscordContent)
+		}
+		for _, e := range body["embeds"].([]any) {
+			title, _ := e.(map[string]any)["title"].(string)
+			if utf8.RuneCountInString(title) > DiscordEmbedTitle {
+				t.Errorf("message %d embed title is %d chars, over DiscordEmbedTitle", i+1, utf8.RuneCountInString(title))
+			}
+			if strings.HasSuffix(title, discordContinuedSuffix) {
+				continued = true
+			}
+		}
+	}
+	if !continued {
+		t.Error("continued embeds should keep the suffix after truncation")
+	}
+}
+
+func TestDeliverSkipsSentBatches(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		texts = append(texts, body["markdown"].(map[string]any)["text"].(string))
+		if len(texts) == 2 {
+			// 首次发送的第2批失败
+			w.Write([]byte(`{"errcode":130101,"errmsg":"send too fast"}`))
+			return
+		}
+		w.Write([]byte(`{"errcode":0}`))
+	}))
+	defer server.Close()
+	n := NewDingtalkNotifier(server.URL, DingtalkOptions{})
+	n.setBatchOptions(BatchOptions{Size: 2000})
+	items := longNews(40, "")
+
+	first := deliver(n, items, 0)
+	if first.Status != model.DeliveryFailed || first.Batches != 1 {
+		t.Fatalf("first delivery = %+v, want 1 batch sent before failing", first)
+	}
+	retry := deliver(n, items, first.Batches)
+	if retry.Status != model.DeliverySuccess {
+		t.Fatalf("retry = %+v", retry)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if retry.Batches != len(texts)-2 {
+		t.Errorf("retry counted %d batches, sent %d", retry.Batches, len(texts)-2)
+	}
+	if texts[2] != texts[1] {
+		t.Errorf("retry should resume from the failed batch, got:\n%s", texts[2])
+	}
+	for i, text := range texts[2:] {
+		if text == texts[0] {
+			t.Errorf("retry message %d repeats the batch already sent", i+1)
+		}
+	}
+}
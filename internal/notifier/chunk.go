@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// chunkLines 把多行内容按字节数上限拆成多段，尽量不在行中间截断
+// lines 中的每一行应包含结尾的换行符；单行超过上限时按字符截断，不会截断 UTF-8 字符
+func chunkLines(lines []string, limit int) []string {
+	return splitLines(lines, limit, utf8.RuneLen)
+}
+
+// chunkLinesByChars 与 chunkLines 相同，但按字符数计算长度，用于按字符限制消息长度的平台
+func chunkLinesByChars(lines []string, limit int) []string {
+	return splitLines(lines, limit, func(rune) int { return 1 })
+}
+
+func splitLines(lines []string, limit int, size func(rune) int) []string {
+	var chunks []string
+	var sb strings.Builder
+	current := 0
+	flush := func() {
+		if sb.Len() > 0 {
+			chunks = append(chunks, sb.String())
+			sb.Reset()
+			current = 0
+		}
+	}
+
+	for _, line := range lines {
+		n := 0
+		for _, r := range line {
+			n += size(r)
+		}
+		if current+n > limit {
+			flush()
+		}
+		if n <= limit {
+			sb.WriteString(line)
+			current += n
+			continue
+		}
+
+		// 单行超过上限，按字符截断
+		for _, r := range line {
+			if current+size(r) > limit {
+				flush()
+			}
+			sb.WriteRune(r)
+			current += size(r)
+		}
+	}
+	flush()
+	return chunks
+}
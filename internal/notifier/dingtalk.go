@@ -3,21 +3,79 @@ package notifier
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
+// 钉钉自定义机器人的消息类型
+const (
+	DingtalkMsgTypeMarkdown   = "markdown"   // Markdown 文本，默认
+	DingtalkMsgTypeActionCard = "actionCard" // 卡片，正文为 Markdown，底部带跳转按钮
+	DingtalkMsgTypeFeedCard   = "feedCard"   // 图文列表，每条新闻一行
+)
+
+// 钉钉消息的限制
+const (
+	DingtalkFeedCardLimit      = 10    // 每条 FeedCard 消息的新闻条数
+	DingtalkMessageLimit       = 20000 // Markdown、ActionCard 正文的字节数上限
+	DingtalkActionCardButtons  = 3     // 未配置 app.public_url 时，ActionCard 底部显示的热门新闻按钮数
+	DefaultDingtalkAtPriority  = 8     // 默认的 @ 提醒关键词组优先级阈值
+	dingtalkActionCardBtnTitle = "查看完整报告"
+)
+
+// DingtalkOptions 钉钉消息的可选参数
+type DingtalkOptions struct {
+	MsgType       string         // markdown、actionCard 或 feedCard，留空使用 markdown
+	Secret        string         // 机器人安全设置中的加签密钥，未开启加签时留空
+	AtMobiles     []string       // 高优先级关键词组有新闻时 @ 的手机号
+	AtPriority    int            // 触发 @ 的关键词组优先级阈值，0 表示使用 DefaultDingtalkAtPriority
+	GroupPriority map[string]int // 关键词组标识到优先级的映射
+	PublicURL     string         // Web 界面的外部访问地址，ActionCard 的按钮跳转到这里
+}
+
 type DingtalkNotifier struct {
+	batching
 	webhookURL string
+	opts       DingtalkOptions
+	tmpl       *MessageTemplate
+}
+
+// NewDingtalkNotifier 创建钉钉群机器人通知器
+func NewDingtalkNotifier(url string, opts DingtalkOptions) *DingtalkNotifier {
+	switch strings.ToLower(strings.TrimSpace(opts.MsgType)) {
+	case "actioncard":
+		opts.MsgType = DingtalkMsgTypeActionCard
+	case "feedcard":
+		opts.MsgType = DingtalkMsgTypeFeedCard
+	default:
+		opts.MsgType = DingtalkMsgTypeMarkdown
+	}
+	opts.Secret = strings.TrimSpace(opts.Secret)
+	if opts.AtPriority <= 0 {
+		opts.AtPriority = DefaultDingtalkAtPriority
+	}
+	return &DingtalkNotifier{webhookURL: url, opts: opts, tmpl: defaultMessageTemplate("dingtalk")}
 }
 
-func NewDingtalkNotifier(url string) *DingtalkNotifier {
-	return &DingtalkNotifier{webhookURL: url}
+func (n *DingtalkNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("dingtalk", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
 }
 
 func (n *DingtalkNotifier) Name() string {
@@ -25,42 +83,246 @@ func (n *DingtalkNotifier) Name() string {
 }
 
 type DingtalkMessage struct {
-	MsgType  string `json:"msgtype"`
-	Markdown struct {
-		Title string `json:"title"`
-		Text  string `json:"text"`
-	} `json:"markdown"`
+	MsgType    string              `json:"msgtype"`
+	Text       *DingtalkText       `json:"text,omitempty"`
+	Markdown   *DingtalkMarkdown   `json:"markdown,omitempty"`
+	ActionCard *DingtalkActionCard `json:"actionCard,omitempty"`
+	FeedCard   *DingtalkFeedCard   `json:"feedCard,omitempty"`
+	At         *DingtalkAt         `json:"at,omitempty"`
+}
+
+type DingtalkText struct {
+	Content string `json:"content"`
+}
+
+type DingtalkMarkdown struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// DingtalkActionCard 卡片消息，配置了 SingleURL 时为整体跳转，否则为 Btns 中的独立跳转按钮
+type DingtalkActionCard struct {
+	Title          string           `json:"title"`
+	Text           string           `json:"text"`
+	BtnOrientation string           `json:"btnOrientation,omitempty"` // 0 按钮竖向排列，1 横向排列
+	SingleTitle    string           `json:"singleTitle,omitempty"`
+	SingleURL      string           `json:"singleURL,omitempty"`
+	Btns           []DingtalkButton `json:"btns,omitempty"`
+}
+
+type DingtalkButton struct {
+	Title     string `json:"title"`
+	ActionURL string `json:"actionURL"`
+}
+
+type DingtalkFeedCard struct {
+	Links []DingtalkFeedLink `json:"links"`
+}
+
+type DingtalkFeedLink struct {
+	Title      string `json:"title"`
+	MessageURL string `json:"messageURL"`
+	PicURL     string `json:"picURL"`
+}
+
+// DingtalkAt 消息中 @ 的成员，被 @ 的手机号还需要以 @手机号 的形式出现在正文中
+type DingtalkAt struct {
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+// dingtalkResponse 钉钉接口的响应，HTTP 状态码为200时也可能通过 errcode 返回错误
+type dingtalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
 }
 
 func (n *DingtalkNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("# TrendHub 热点监控报告 (%s)\n\n", time.Now().Format("15:04")))
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+
+	// 高优先级关键词组有新闻时 @ 指定成员：Markdown 消息在第一批中 @，分批时预留 @ 文本的长度；卡片消息不支持 @，另发一条文本消息
+	mention := n.mention(content.Title, items)
 
-	currentSource := ""
+	var messages []DingtalkMessage
+	switch n.opts.MsgType {
+	case DingtalkMsgTypeActionCard:
+		messages = n.actionCardMessages(content, items)
+	case DingtalkMsgTypeFeedCard:
+		messages, err = n.feedCardMessages(items)
+		if err != nil {
+			return err
+		}
+	default:
+		reserve := 0
+		if mention != "" {
+			reserve = len("\n\n" + mention)
+		}
+		messages = n.markdownMessages(content, reserve)
+	}
+
+	if mention != "" {
+		at := &DingtalkAt{AtMobiles: n.opts.AtMobiles}
+		if n.opts.MsgType == DingtalkMsgTypeMarkdown {
+			messages[0].Markdown.Text += "\n\n" + mention
+			messages[0].At = at
+		} else {
+			messages = append(messages, DingtalkMessage{
+				MsgType: "text",
+				Text:    &DingtalkText{Content: mention},
+				At:      at,
+			})
+		}
+	}
+
+	return n.sendBatches(ctx, len(messages), func(i int) error {
+		return n.post(ctx, messages[i])
+	})
+}
+
+// markdownMessages 生成 Markdown 消息，按 dingtalk_batch_size（不超过 DingtalkMessageLimit）拆成多条
+// reserve 为每条消息预留的字节数，用于之后附加的 @ 文本
+func (n *DingtalkNotifier) markdownMessages(content *renderedMessage, reserve int) []DingtalkMessage {
+	batches := content.batches(n.batch.limit(DingtalkMessageLimit)-reserve, n.batch.Separator)
+	messages := make([]DingtalkMessage, len(batches))
+	for i, batch := range batches {
+		messages[i] = DingtalkMessage{
+			MsgType: DingtalkMsgTypeMarkdown,
+			Markdown: &DingtalkMarkdown{
+				Title: content.Title + pageMarker(i, len(batches)),
+				Text:  batch.text(),
+			},
+		}
+	}
+	return messages
+}
+
+// actionCardMessages 生成 ActionCard 消息，正文与 Markdown 消息相同
+// 配置了 app.public_url 时底部为“查看完整报告”按钮，否则为排名最靠前的几条新闻的按钮
+func (n *DingtalkNotifier) actionCardMessages(content *renderedMessage, items []*model.NewsItem) []DingtalkMessage {
+	var buttons []DingtalkButton
+	if n.opts.PublicURL == "" {
+		for _, item := range items {
+			if len(buttons) == DingtalkActionCardButtons {
+				break
+			}
+			if link := newsLink(item); link != "" {
+				buttons = append(buttons, DingtalkButton{Title: item.Title, ActionURL: link})
+			}
+		}
+	}
+
+	batches := content.batches(n.batch.limit(DingtalkMessageLimit), n.batch.Separator)
+	messages := make([]DingtalkMessage, len(batches))
+	for i, batch := range batches {
+		card := &DingtalkActionCard{
+			Title:          content.Title + pageMarker(i, len(batches)),
+			Text:           batch.text(),
+			BtnOrientation: "0",
+		}
+		if n.opts.PublicURL != "" {
+			card.SingleTitle = dingtalkActionCardBtnTitle
+			card.SingleURL = n.opts.PublicURL
+		} else {
+			card.Btns = buttons
+		}
+		messages[i] = DingtalkMessage{MsgType: DingtalkMsgTypeActionCard, ActionCard: card}
+	}
+	return messages
+}
+
+// feedCardMessages 生成 FeedCard 消息，每条最多10条新闻；没有链接的新闻无法放入 FeedCard，改用 Markdown 发送
+// FeedCard 只显示标题，不使用消息模板
+func (n *DingtalkNotifier) feedCardMessages(items []*model.NewsItem) ([]DingtalkMessage, error) {
+	var links []DingtalkFeedLink
+	var noLink []*model.NewsItem
 	for _, item := range items {
-		if item.SourceName != currentSource {
-			sb.WriteString(fmt.Sprintf("\n## %s\n", item.SourceName))
-			currentSource = item.SourceName
+		link := newsLink(item)
+		if link == "" {
+			noLink = append(noLink, item)
+			continue
 		}
-		title := item.Title
-		if item.URL != "" {
-			title = fmt.Sprintf("[%s](%s)", item.Title, item.URL)
+		links = append(links, DingtalkFeedLink{
+			Title:      fmt.Sprintf("%s｜%s", item.SourceName, item.Title),
+			MessageURL: link,
+		})
+	}
+
+	var messages []DingtalkMessage
+	for start := 0; start < len(links); start += DingtalkFeedCardLimit {
+		end := start + DingtalkFeedCardLimit
+		if end > len(links) {
+			end = len(links)
+		}
+		messages = append(messages, DingtalkMessage{
+			MsgType:  DingtalkMsgTypeFeedCard,
+			FeedCard: &DingtalkFeedCard{Links: links[start:end]},
+		})
+	}
+	if len(noLink) > 0 || len(messages) == 0 {
+		content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: noLink})
+		if err != nil {
+			return nil, err
 		}
-		sb.WriteString(fmt.Sprintf("- **%d.** %s\n", item.Ranks[0], title))
+		messages = append(messages, n.markdownMessages(content, 0)...)
 	}
+	return messages, nil
+}
 
-	msg := DingtalkMessage{
-		MsgType: "markdown",
+// mention 返回 @ 提醒的文本，没有配置手机号或没有高优先级关键词组的新闻时为空
+func (n *DingtalkNotifier) mention(title string, items []*model.NewsItem) string {
+	if len(n.opts.AtMobiles) == 0 {
+		return ""
+	}
+	var groups []string
+	counts := make(map[string]int)
+	for _, item := range items {
+		key := item.KeywordGroupKey
+		if key == "" || n.opts.GroupPriority[key] < n.opts.AtPriority {
+			continue
+		}
+		if counts[key] == 0 {
+			groups = append(groups, key)
+		}
+		counts[key]++
+	}
+	if len(groups) == 0 {
+		return ""
 	}
-	msg.Markdown.Title = "TrendHub 热点监控"
-	msg.Markdown.Text = sb.String()
 
+	var sb strings.Builder
+	sb.WriteString(title + "：")
+	for i, group := range groups {
+		if i > 0 {
+			sb.WriteString("、")
+		}
+		fmt.Fprintf(&sb, "「%s」%d 条", group, counts[group])
+	}
+	for _, mobile := range n.opts.AtMobiles {
+		sb.WriteString(" @" + mobile)
+	}
+	return sb.String()
+}
+
+func (n *DingtalkNotifier) post(ctx context.Context, msg DingtalkMessage) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, bytes.NewBuffer(data))
+	webhookURL := n.webhookURL
+	if n.opts.Secret != "" {
+		timestamp := time.Now().UnixMilli()
+		sep := "?"
+		if strings.Contains(webhookURL, "?") {
+			sep = "&"
+		}
+		webhookURL += sep + "timestamp=" + strconv.FormatInt(timestamp, 10) + "&sign=" + url.QueryEscape(dingtalkSign(n.opts.Secret, timestamp))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
@@ -73,10 +335,26 @@ func (n *DingtalkNotifier) Send(ctx context.Context, items []*model.NewsItem) er
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("dingtalk api status code: %d", resp.StatusCode)
+		return fmt.Errorf("dingtalk api status code: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result dingtalkResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("decode dingtalk response failed: %w", err)
+	}
+	// 常见错误：310000 加签校验失败、时间戳与服务器相差超过1小时、未包含自定义关键词或 IP 不在白名单，130101 发送频率超限
+	if result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk api error %d: %s", result.ErrCode, result.ErrMsg)
 	}
 
 	return nil
 }
 
+// dingtalkSign 计算加签：以密钥对“时间戳（毫秒）\n密钥”做 HMAC-SHA256，再进行 Base64 编码
+func dingtalkSign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
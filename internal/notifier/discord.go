@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// Discord Webhook 的消息限制
+const (
+	DiscordMaxEmbeds       = 10   // 每条消息最多的 embed 数
+	DiscordEmbedDesc       = 4096 // embed 描述的字符数上限
+	DiscordMessageChars    = 6000 // 一条消息中所有 embed 的标题和描述的字符数之和上限
+	DiscordContent         = 2000 // 消息 content 的字符数上限
+	DiscordEmbedTitle      = 256  // embed 标题的字符数上限
+	discordEmbedColor      = 0x667eea
+	discordContinuedSuffix = "（续）"
+)
+
+// DiscordNotifier 通过 Discord Webhook 发送消息，每个平台一个 embed
+type DiscordNotifier struct {
+	batching
+	webhookURL string
+	tmpl       *MessageTemplate
+}
+
+func NewDiscordNotifier(url string) *DiscordNotifier {
+	return &DiscordNotifier{webhookURL: url, tmpl: defaultMessageTemplate("discord")}
+}
+
+func (n *DiscordNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("discord", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *DiscordNotifier) Name() string {
+	return "Discord"
+}
+
+type DiscordEmbed struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+}
+
+type DiscordMessage struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []DiscordEmbed `json:"embeds"`
+}
+
+func (n *DiscordNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+
+	// 分组标题作为 embed 标题，新闻列表作为 embed 描述
+	var embeds []DiscordEmbed
+	for _, group := range content.Groups {
+		var lines []string
+		for _, item := range group.Items {
+			lines = append(lines, item+"\n")
+		}
+		for i, chunk := range chunkLinesByChars(lines, DiscordEmbedDesc) {
+			// 续页保留“（续）”后缀，只截断分组标题
+			title := truncateText(DiscordEmbedTitle, group.Header)
+			if i > 0 {
+				title = truncateText(DiscordEmbedTitle-utf8.RuneCountInString(discordContinuedSuffix), group.Header) + discordContinuedSuffix
+			}
+			embeds = append(embeds, DiscordEmbed{Title: title, Description: strings.TrimRight(chunk, "\n"), Color: discordEmbedColor})
+		}
+	}
+	if content.Footer != "" {
+		embeds = append(embeds, DiscordEmbed{Description: truncateText(DiscordEmbedDesc, content.Footer), Color: discordEmbedColor})
+	}
+
+	// 按 embed 数和总字符数把 embed 分到多条消息中，每条消息都带标题和页码
+	var messages []DiscordMessage
+	var msg DiscordMessage
+	chars := 0
+	for _, embed := range embeds {
+		size := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+		if len(msg.Embeds) > 0 && (len(msg.Embeds) >= DiscordMaxEmbeds || chars+size > DiscordMessageChars) {
+			messages = append(messages, msg)
+			msg = DiscordMessage{}
+			chars = 0
+		}
+		msg.Embeds = append(msg.Embeds, embed)
+		chars += size
+	}
+	messages = append(messages, msg)
+
+	return n.sendBatches(ctx, len(messages), func(i int) error {
+		msg := messages[i]
+		marker := pageMarker(i, len(messages))
+		msg.Content = truncateText(DiscordContent-utf8.RuneCountInString(marker), content.Header) + marker
+		return n.post(ctx, msg)
+	})
+}
+
+func (n *DiscordNotifier) post(ctx context.Context, msg DiscordMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 成功时返回 204，带 ?wait=true 时返回 200
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord api status code: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// smtpPresets 常用邮箱服务的 SMTP 地址，未配置服务器时按发件人域名识别
+var smtpPresets = map[string]struct {
+	host string
+	port string
+}{
+	"qq.com":      {"smtp.qq.com", "465"},
+	"foxmail.com": {"smtp.qq.com", "465"},
+	"163.com":     {"smtp.163.com", "465"},
+	"126.com":     {"smtp.126.com", "465"},
+	"yeah.net":    {"smtp.yeah.net", "465"},
+	"sina.com":    {"smtp.sina.com", "465"},
+	"sohu.com":    {"smtp.sohu.com", "465"},
+	"aliyun.com":  {"smtp.aliyun.com", "465"},
+	"gmail.com":   {"smtp.gmail.com", "587"},
+	"outlook.com": {"smtp.office365.com", "587"},
+	"hotmail.com": {"smtp.office365.com", "587"},
+	"live.com":    {"smtp.office365.com", "587"},
+	"icloud.com":  {"smtp.mail.me.com", "587"},
+	"yahoo.com":   {"smtp.mail.yahoo.com", "465"},
+}
+
+// EmailNotifier 通过 SMTP 发送 HTML + 纯文本的邮件摘要
+// 端口为 465 时使用隐式 TLS，其他端口通过 STARTTLS 升级为加密连接
+type EmailNotifier struct {
+	from           string
+	password       string
+	to             []string
+	host           string
+	port           string
+	allowPlaintext bool
+	text           *MessageTemplate // 纯文本正文，title 同时用作邮件主题
+	html           *MessageTemplate
+}
+
+// NewEmailNotifier 创建邮件通知器
+// to: 收件人，多个用逗号或分号分隔
+// host/port: SMTP 服务器，留空时按发件人域名识别常用邮箱，识别不到时使用 smtp.<域名>:587
+// allowPlaintext: 服务器不支持 STARTTLS 时是否以明文发送，为 false 时发送失败
+func NewEmailNotifier(from, password, to, host, port string, allowPlaintext bool) *EmailNotifier {
+	from = strings.TrimSpace(from)
+	host = strings.TrimSpace(host)
+	port = strings.TrimSpace(port)
+
+	if host == "" || port == "" {
+		domain := ""
+		if i := strings.LastIndex(from, "@"); i >= 0 {
+			domain = strings.ToLower(strings.TrimRight(from[i+1:], ">"))
+		}
+		preset, ok := smtpPresets[domain]
+		if !ok && domain != "" {
+			preset.host, preset.port = "smtp."+domain, "587"
+		}
+		if host == "" {
+			host = preset.host
+		}
+		if port == "" {
+			port = preset.port
+		}
+	}
+
+	return &EmailNotifier{
+		from:           from,
+		password:       password,
+		to:             splitAddresses(to),
+		host:           host,
+		port:           port,
+		allowPlaintext: allowPlaintext,
+		text:           defaultMessageTemplate("email"),
+		html:           defaultMessageTemplate("email_html"),
+	}
+}
+
+func (n *EmailNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	text, err := newMessageTemplate("email", cfg)
+	if err != nil {
+		return err
+	}
+	html, err := newMessageTemplate("email_html", cfg)
+	if err != nil {
+		return err
+	}
+	n.text, n.html = text, html
+	return nil
+}
+
+func (n *EmailNotifier) Name() string {
+	return "Email"
+}
+
+// splitAddresses 拆分逗号、分号或空白分隔的收件人
+func splitAddresses(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	if n.host == "" || n.port == "" {
+		return fmt.Errorf("smtp server is not configured and cannot be detected from %q", n.from)
+	}
+	if len(n.to) == 0 {
+		return fmt.Errorf("email recipient is empty")
+	}
+
+	from, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return fmt.Errorf("invalid email_from %q: %w", n.from, err)
+	}
+	to := make([]string, len(n.to))
+	for i, addr := range n.to {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("invalid email_to %q: %w", addr, err)
+		}
+		to[i] = parsed.Address
+	}
+
+	now := time.Now()
+	msg, err := n.buildMessage(from, to, items, now)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.password != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support AUTH", n.host)
+		}
+		// PlainAuth 只允许在加密连接或本机上发送密码
+		if err := client.Auth(smtp.PlainAuth("", from.Address, n.password, n.host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", addr, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write email body failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial 连接 SMTP 服务器：465 端口使用隐式 TLS，其他端口使用 STARTTLS
+// 服务器不支持 STARTTLS 时，除非配置了 email_allow_plaintext，否则不发送，避免邮件内容被明文传输
+func (n *EmailNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.host, n.port)
+	tlsConfig := &tls.Config{ServerName: n.host}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect smtp server %s failed: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if n.port == "465" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake with %s failed: %w", addr, err)
+	}
+	if n.port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("smtp STARTTLS failed: %w", err)
+			}
+		} else if !n.allowPlaintext {
+			client.Close()
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS, use port 465 or set email_allow_plaintext to send in plaintext", addr)
+		}
+	}
+	return client, nil
+}
+
+// buildMessage 生成 multipart/alternative 邮件，包含纯文本和 HTML 两个版本
+func (n *EmailNotifier) buildMessage(from *mail.Address, to []string, items []*model.NewsItem, now time.Time) ([]byte, error) {
+	text, err := n.text.render(&MessageData{Time: now, Items: items})
+	if err != nil {
+		return nil, err
+	}
+	html, err := n.html.render(&MessageData{Time: now, Items: items})
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", text.text()},
+		{"text/html; charset=UTF-8", emailHTML(html)},
+	}
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, []byte(p.content))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	// title 模板可能输出多行，换行会被当作新的邮件头，合并为一行
+	subject := strings.Join(strings.Fields(text.Title), " ")
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.BEncoding.Encode("UTF-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	for _, h := range headers {
+		msg.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeBase64 按每行76个字符写入 base64 编码的内容
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+func messageID(from string) string {
+	domain := "trendhub.local"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
+
+// emailHTML 把 email_html 模板生成的各部分组装为 HTML 邮件，分组标题和新闻由模板生成内容，外层标签和样式固定
+func emailHTML(content *renderedMessage) string {
+	var sb strings.Builder
+	sb.WriteString(`<!DOCTYPE html><html><head><meta charset="UTF-8"></head>`)
+	sb.WriteString(`<body style="font-family: -apple-system, 'PingFang SC', 'Microsoft YaHei', sans-serif; color: #1f2937; line-height: 1.6;">`)
+	sb.WriteString(content.Header)
+
+	for _, group := range content.Groups {
+		if group.Header != "" {
+			sb.WriteString(fmt.Sprintf(`<h3 style="margin: 20px 0 8px; color: #4f46e5;">%s</h3>`, group.Header))
+		}
+		sb.WriteString(`<ol style="padding-left: 0; list-style: none;">`)
+		for _, item := range group.Items {
+			sb.WriteString(fmt.Sprintf(`<li style="margin: 4px 0;">%s</li>`, item))
+		}
+		sb.WriteString("</ol>")
+	}
+	if content.Footer != "" {
+		sb.WriteString(fmt.Sprintf(`<div style="margin-top: 20px; color: #6b7280;">%s</div>`, content.Footer))
+	}
+	sb.WriteString("</body></html>")
+	return sb.String()
+}
@@ -0,0 +1,223 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// smtpStub 进程内的 SMTP 服务器，记录收到的发件人、收件人和邮件内容，不支持 STARTTLS 和认证
+type smtpStub struct {
+	listener net.Listener
+	done     chan struct{}
+	from     string
+	rcpt     []string
+	data     string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{listener: l, done: make(chan struct{})}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStub) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+// serve 处理一个连接
+func (s *smtpStub) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-stub")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = smtpPath(line)
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.rcpt = append(s.rcpt, smtpPath(line))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var sb strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				sb.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.data = sb.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// smtpPath 取出 MAIL FROM、RCPT TO 命令中尖括号内的地址
+func smtpPath(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func emailTestItems() []*model.NewsItem {
+	return []*model.NewsItem{
+		{Title: "新闻<一>", URL: "https://example.com/1", SourceID: "weibo", SourceName: "微博", Ranks: []int{1}},
+		{Title: "新闻二", URL: "https://example.com/2", SourceID: "zhihu", SourceName: "知乎", Ranks: []int{3}},
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	s := newSMTPStub(t)
+	n := NewEmailNotifier("TrendHub <bot@example.com>", "", "a@example.com; b@example.com,c@example.com", "127.0.0.1", s.port(), true)
+	if err := n.Send(context.Background(), emailTestItems()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.done
+
+	if s.from != "bot@example.com" {
+		t.Errorf("MAIL FROM = %q", s.from)
+	}
+	wantRcpt := []string{"a@example.com", "b@example.com", "c@example.com"}
+	if strings.Join(s.rcpt, ",") != strings.Join(wantRcpt, ",") {
+		t.Errorf("RCPT TO = %v, want %v", s.rcpt, wantRcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("To"); got != strings.Join(wantRcpt, ", ") {
+		t.Errorf("To = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "TrendHub 热点监控报告" {
+		t.Errorf("Subject = %q, want the rendered title", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", msg.Header.Get("Content-Type"), err)
+	}
+	parts := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc := p.Header.Get("Content-Transfer-Encoding"); enc != "base64" {
+			t.Errorf("Content-Transfer-Encoding = %q", enc)
+		}
+		body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[mediaType] = string(body)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("got parts %v, want text/plain and text/html", parts)
+	}
+	if text := parts["text/plain"]; !strings.Contains(text, "新闻<一>") || !strings.Contains(text, "https://example.com/2") {
+		t.Errorf("text part missing news:\n%s", text)
+	}
+	if html := parts["text/html"]; !strings.Contains(html, `<a href="https://example.com/1"`) || !strings.Contains(html, "新闻&lt;一&gt;") {
+		t.Errorf("html part missing escaped news:\n%s", html)
+	}
+}
+
+func TestEmailSubjectSingleLine(t *testing.T) {
+	n := NewEmailNotifier("bot@example.com", "", "a@example.com", "127.0.0.1", "25", false)
+	err := n.setTemplates(config.TemplatesConfig{Channels: map[string]map[string]string{
+		"email": {"title": "日报\r\nBcc: victim@example.com\n"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := &mail.Address{Address: "bot@example.com"}
+	data, err := n.buildMessage(from, []string{"a@example.com"}, emailTestItems(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("title injected a Bcc header: %q", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "日报 Bcc: victim@example.com" {
+		t.Errorf("Subject = %q", subject)
+	}
+}
+
+func TestEmailRequiresSTARTTLS(t *testing.T) {
+	s := newSMTPStub(t)
+	n := NewEmailNotifier("bot@example.com", "", "a@example.com", "127.0.0.1", s.port(), false)
+	err := n.Send(context.Background(), emailTestItems())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("Send without STARTTLS = %v, want an error", err)
+	}
+	<-s.done
+	if s.from != "" || len(s.rcpt) != 0 || s.data != "" {
+		t.Errorf("mail sent in plaintext: from %q, rcpt %v", s.from, s.rcpt)
+	}
+}
@@ -3,21 +3,72 @@ package notifier
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
+// 飞书自定义机器人的消息类型
+const (
+	FeishuMsgTypeText        = "text"        // 纯文本，默认
+	FeishuMsgTypeInteractive = "interactive" // 消息卡片，每个分组一个区块，新闻标题为链接
+)
+
+// 飞书限制请求体不超过 30KB，这里为消息的其他字段和 JSON 转义留出余量
+const (
+	FeishuCardLimit = 28000 // 每张消息卡片中内容编码为 JSON 后的字节数上限
+	FeishuTextLimit = 28000 // 纯文本消息正文的字节数上限
+)
+
 type FeishuNotifier struct {
+	batching
 	webhookURL string
+	msgType    string
+	secret     string
+	tmpl       *MessageTemplate
+}
+
+// NewFeishuNotifier 创建飞书群机器人通知器
+// msgType: text 或 interactive，留空使用 text
+// secret: 机器人安全设置中的签名校验密钥，未开启签名校验时留空
+func NewFeishuNotifier(url, msgType, secret string) *FeishuNotifier {
+	msgType = strings.ToLower(strings.TrimSpace(msgType))
+	if msgType != FeishuMsgTypeInteractive {
+		msgType = FeishuMsgTypeText
+	}
+	return &FeishuNotifier{
+		webhookURL: url,
+		msgType:    msgType,
+		secret:     strings.TrimSpace(secret),
+		tmpl:       defaultMessageTemplate(feishuTemplate(msgType)),
+	}
 }
 
-func NewFeishuNotifier(url string) *FeishuNotifier {
-	return &FeishuNotifier{webhookURL: url}
+// feishuTemplate 返回飞书使用的模板渠道名，消息卡片使用 feishu_card
+func feishuTemplate(msgType string) string {
+	if msgType == FeishuMsgTypeInteractive {
+		return "feishu_card"
+	}
+	return "feishu"
+}
+
+func (n *FeishuNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate(feishuTemplate(n.msgType), cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
 }
 
 func (n *FeishuNotifier) Name() string {
@@ -25,40 +76,157 @@ func (n *FeishuNotifier) Name() string {
 }
 
 type FeishuMessage struct {
-	MsgType string `json:"msg_type"`
-	Content struct {
-		Text string `json:"text"`
-	} `json:"content"`
+	Timestamp string         `json:"timestamp,omitempty"` // 开启签名校验时的时间戳（秒）
+	Sign      string         `json:"sign,omitempty"`
+	MsgType   string         `json:"msg_type"`
+	Content   *FeishuContent `json:"content,omitempty"`
+	Card      *FeishuCard    `json:"card,omitempty"`
+}
+
+type FeishuContent struct {
+	Text string `json:"text"`
+}
+
+// FeishuCard 消息卡片
+type FeishuCard struct {
+	Config   FeishuCardConfig    `json:"config"`
+	Header   FeishuCardHeader    `json:"header"`
+	Elements []FeishuCardElement `json:"elements"`
+}
+
+type FeishuCardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+type FeishuCardHeader struct {
+	Title    FeishuCardText `json:"title"`
+	Template string         `json:"template,omitempty"` // 标题栏颜色，如 blue
+}
+
+// FeishuCardText 卡片中的文本，tag 为 plain_text 或 lark_md
+type FeishuCardText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// FeishuCardElement 卡片内容中的元素，div 为文本区块，hr 为分割线
+type FeishuCardElement struct {
+	Tag  string          `json:"tag"`
+	Text *FeishuCardText `json:"text,omitempty"`
+}
+
+// feishuResponse 飞书接口的响应，HTTP 状态码为200时也可能通过 code 返回错误
+// 旧版接口使用 StatusCode 和 StatusMessage
+type feishuResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
 }
 
 func (n *FeishuNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("TrendHub 热点监控报告 (%s)\n\n", time.Now().Format("2006-01-02 15:04")))
-
-	// 简单的文本格式化
-	currentSource := ""
-	for _, item := range items {
-		if item.SourceName != currentSource {
-			sb.WriteString(fmt.Sprintf("\n【%s】\n", item.SourceName))
-			currentSource = item.SourceName
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+
+	var messages []FeishuMessage
+	if n.msgType == FeishuMsgTypeInteractive {
+		messages = n.cardMessages(content)
+	} else {
+		// 按 feishu_batch_size（不超过 FeishuTextLimit）分批，分组之间使用 feishu_message_separator 分隔
+		for _, batch := range content.batches(n.batch.limit(FeishuTextLimit), n.batch.Separator) {
+			messages = append(messages, FeishuMessage{
+				MsgType: FeishuMsgTypeText,
+				Content: &FeishuContent{Text: batch.text()},
+			})
 		}
-		sb.WriteString(fmt.Sprintf("%d. %s\n", item.Ranks[0], item.Title))
-		if item.URL != "" {
-			sb.WriteString(fmt.Sprintf("   %s\n", item.URL))
+	}
+
+	return n.sendBatches(ctx, len(messages), func(i int) error {
+		return n.post(ctx, messages[i])
+	})
+}
+
+// cardMessages 生成消息卡片：header 模板作为卡片标题，每个分组一个 lark_md 区块，区块之间用分割线隔开
+// 按 feishu_batch_size（不超过 FeishuCardLimit）把区块分到多张卡片中，分组被拆开时标题附加“（续）”
+func (n *FeishuNotifier) cardMessages(content *renderedMessage) []FeishuMessage {
+	limit := n.batch.limit(FeishuCardLimit)
+
+	var sections []string
+	for _, group := range content.Groups {
+		var lines []string
+		for _, item := range group.Items {
+			lines = append(lines, item+"\n")
+		}
+		for i, chunk := range chunkLines(lines, limit) {
+			header := group.Header
+			if header != "" && i > 0 {
+				header += batchContinuedSuffix
+			}
+			section := strings.TrimRight(chunk, "\n")
+			if header != "" {
+				section = header + "\n" + section
+			}
+			sections = append(sections, section)
 		}
 	}
+	if content.Footer != "" {
+		sections = append(sections, content.Footer)
+	}
 
-	msg := FeishuMessage{
-		MsgType: "text",
+	var cards [][]FeishuCardElement
+	var elements []FeishuCardElement
+	size := 0
+	for _, section := range sections {
+		sectionSize := jsonSize(section)
+		if size > 0 && size+sectionSize > limit {
+			cards = append(cards, elements)
+			elements, size = nil, 0
+		}
+		if len(elements) > 0 {
+			elements = append(elements, FeishuCardElement{Tag: "hr"})
+		}
+		elements = append(elements, FeishuCardElement{Tag: "div", Text: &FeishuCardText{Tag: "lark_md", Content: section}})
+		size += sectionSize
 	}
-	msg.Content.Text = sb.String()
+	cards = append(cards, elements)
 
-	data, err := json.Marshal(msg)
-	if err != nil {
+	messages := make([]FeishuMessage, len(cards))
+	for i, elements := range cards {
+		if elements == nil {
+			elements = []FeishuCardElement{}
+		}
+		messages[i] = FeishuMessage{
+			MsgType: FeishuMsgTypeInteractive,
+			Card: &FeishuCard{
+				Config: FeishuCardConfig{WideScreenMode: true},
+				Header: FeishuCardHeader{
+					Title:    FeishuCardText{Tag: "plain_text", Content: content.Header + pageMarker(i, len(cards))},
+					Template: "blue",
+				},
+				Elements: elements,
+			},
+		}
+	}
+	return messages
+}
+
+func (n *FeishuNotifier) post(ctx context.Context, msg FeishuMessage) error {
+	if n.secret != "" {
+		timestamp := time.Now().Unix()
+		msg.Timestamp = strconv.FormatInt(timestamp, 10)
+		msg.Sign = feishuSign(n.secret, timestamp)
+	}
+
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false) // 卡片中的 <font> 标签不转义为 \u003c，减小消息体积
+	if err := enc.Encode(msg); err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, &data)
 	if err != nil {
 		return err
 	}
@@ -71,10 +239,37 @@ func (n *FeishuNotifier) Send(ctx context.Context, items []*model.NewsItem) erro
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var result feishuResponse
+	decodeErr := json.Unmarshal(body, &result)
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("feishu api status code: %d", resp.StatusCode)
+		if decodeErr == nil && result.Code != 0 {
+			return fmt.Errorf("feishu api status code: %d, error %d: %s", resp.StatusCode, result.Code, result.Msg)
+		}
+		return fmt.Errorf("feishu api status code: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("decode feishu response failed: %w", decodeErr)
+	}
+	// 常见错误：19021 签名校验失败或时间戳与服务器相差超过1小时，19024 关键词校验失败，11232 发送频率超限
+	if result.Code != 0 {
+		return fmt.Errorf("feishu api error %d: %s", result.Code, result.Msg)
+	}
+	if result.StatusCode != 0 {
+		return fmt.Errorf("feishu api error %d: %s", result.StatusCode, result.StatusMessage)
 	}
 
 	return nil
 }
 
+// larkMDEscape 把 lark_md 中有特殊含义的字符转义为 HTML 实体，避免新闻标题破坏链接、加粗等格式
+func larkMDEscape(s string) string {
+	return strings.NewReplacer("<", "&#60;", ">", "&#62;", "[", "&#91;", "]", "&#93;", "*", "&#42;", "~", "&#126;").Replace(s)
+}
+
+// feishuSign 计算签名：以“时间戳\n密钥”为密钥对空字符串做 HMAC-SHA256，再进行 Base64 编码
+func feishuSign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"strings"
+
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// sourceGroup 同一平台的新闻
+type sourceGroup struct {
+	Name  string
+	Items []*model.NewsItem
+}
+
+// groupBySource 按平台分组，平台按首次出现的顺序排列，组内保持原有顺序
+func groupBySource(items []*model.NewsItem) []sourceGroup {
+	var groups []sourceGroup
+	index := make(map[string]int)
+	for _, item := range items {
+		i, ok := index[item.SourceName]
+		if !ok {
+			i = len(groups)
+			index[item.SourceName] = i
+			groups = append(groups, sourceGroup{Name: item.SourceName})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	return groups
+}
+
+// itemRank 返回新闻在平台榜单上的排名，缺失时为0
+func itemRank(item *model.NewsItem) int {
+	if len(item.Ranks) == 0 {
+		return 0
+	}
+	return item.Ranks[0]
+}
+
+// newsLink 返回新闻的链接，没有 PC 端链接时使用移动端链接
+func newsLink(item *model.NewsItem) string {
+	if item.URL != "" {
+		return item.URL
+	}
+	return item.MobileURL
+}
+
+// escapeMarkdownLink 转义链接文字中的方括号，避免破坏 Markdown 链接语法
+func escapeMarkdownLink(s string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(s)
+}
@@ -2,10 +2,12 @@ package notifier
 
 import (
 	"context"
-	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/logger"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
@@ -18,19 +20,56 @@ type NotificationManager struct {
 	notifiers []Notifier
 }
 
-func NewNotificationManager(cfg *config.Config) *NotificationManager {
+// NewNotificationManager 按配置创建启用的通知器，groups 为关键词组，用于按优先级 @ 提醒
+func NewNotificationManager(cfg *config.Config, groups []config.KeywordGroup) *NotificationManager {
 	manager := &NotificationManager{}
 
 	if cfg.Notification.Webhooks.FeishuURL != "" {
-		manager.notifiers = append(manager.notifiers, NewFeishuNotifier(cfg.Notification.Webhooks.FeishuURL))
+		webhooks := cfg.Notification.Webhooks
+		manager.notifiers = append(manager.notifiers, NewFeishuNotifier(webhooks.FeishuURL, webhooks.FeishuMsgType, webhooks.FeishuSecret))
 	}
 
 	if cfg.Notification.Webhooks.DingtalkURL != "" {
-		manager.notifiers = append(manager.notifiers, NewDingtalkNotifier(cfg.Notification.Webhooks.DingtalkURL))
+		webhooks := cfg.Notification.Webhooks
+		priorities := make(map[string]int, len(groups))
+		for _, group := range groups {
+			priorities[group.GroupKey] = group.Priority
+		}
+		manager.notifiers = append(manager.notifiers, NewDingtalkNotifier(webhooks.DingtalkURL, DingtalkOptions{
+			MsgType:       webhooks.DingtalkMsgType,
+			Secret:        webhooks.DingtalkSecret,
+			AtMobiles:     splitList(webhooks.DingtalkAtMobiles),
+			AtPriority:    webhooks.DingtalkAtPriority,
+			GroupPriority: priorities,
+			PublicURL:     cfg.App.PublicURL,
+		}))
+	}
+
+	if cfg.Notification.Webhooks.WeworkURL != "" {
+		manager.notifiers = append(manager.notifiers, NewWeworkNotifier(cfg.Notification.Webhooks.WeworkURL, cfg.Notification.Webhooks.WeworkMsgType))
 	}
 
 	if cfg.Notification.Webhooks.TelegramBotToken != "" && cfg.Notification.Webhooks.TelegramChatID != "" {
-		manager.notifiers = append(manager.notifiers, NewTelegramNotifier(cfg.Notification.Webhooks.TelegramBotToken, cfg.Notification.Webhooks.TelegramChatID))
+		webhooks := cfg.Notification.Webhooks
+		manager.notifiers = append(manager.notifiers, NewTelegramNotifier(webhooks.TelegramBotToken, webhooks.TelegramChatID, TelegramOptions{
+			ParseMode:  webhooks.TelegramParseMode,
+			APIBaseURL: webhooks.TelegramAPIBaseURL,
+			ThreadID:   webhooks.TelegramThreadID,
+			Buttons:    webhooks.TelegramButtons,
+			OpenURL:    cfg.App.PublicURL,
+		}))
+	}
+
+	if cfg.Notification.Webhooks.SlackWebhookURL != "" {
+		manager.notifiers = append(manager.notifiers, NewSlackNotifier(cfg.Notification.Webhooks.SlackWebhookURL))
+	}
+
+	if cfg.Notification.Webhooks.DiscordWebhookURL != "" {
+		manager.notifiers = append(manager.notifiers, NewDiscordNotifier(cfg.Notification.Webhooks.DiscordWebhookURL))
+	}
+
+	if cfg.Notification.Webhooks.TeamsWebhookURL != "" {
+		manager.notifiers = append(manager.notifiers, NewTeamsNotifier(cfg.Notification.Webhooks.TeamsWebhookURL))
 	}
 
 	if cfg.Notification.Webhooks.BarkDeviceKey != "" {
@@ -41,25 +80,177 @@ func NewNotificationManager(cfg *config.Config) *NotificationManager {
 		manager.notifiers = append(manager.notifiers, NewWPSNotifier(cfg.Notification.Webhooks.WPSWebhookURL))
 	}
 
+	if cfg.Notification.Webhooks.NtfyTopic != "" {
+		webhooks := cfg.Notification.Webhooks
+		click := webhooks.NtfyClickURL
+		if click == "" {
+			click = cfg.App.PublicURL
+		}
+		tags := splitList(webhooks.NtfyTags)
+		manager.notifiers = append(manager.notifiers, NewNtfyNotifier(webhooks.NtfyServerURL, webhooks.NtfyTopic, NtfyOptions{
+			Token:    webhooks.NtfyToken,
+			Priority: webhooks.NtfyPriority,
+			Tags:     tags,
+			Click:    click,
+			Markdown: webhooks.NtfyMarkdown,
+		}))
+	}
+
+	if cfg.Notification.Webhooks.EmailFrom != "" && cfg.Notification.Webhooks.EmailTo != "" {
+		webhooks := cfg.Notification.Webhooks
+		manager.notifiers = append(manager.notifiers, NewEmailNotifier(webhooks.EmailFrom, webhooks.EmailPassword, webhooks.EmailTo, webhooks.EmailSMTPServer, webhooks.EmailSMTPPort, webhooks.EmailAllowPlaintext))
+	}
+
+	// 名称是自定义 Webhook 在日志、推送记录和重试队列中的标识，重名的只保留第一个
+	webhookNames := make(map[string]bool)
+	for _, hook := range cfg.Notification.CustomWebhooks {
+		n, err := NewWebhookNotifier(hook)
+		if err != nil {
+			logger.Errorf("Skipping custom webhook: %v", err)
+			continue
+		}
+		if webhookNames[n.name] {
+			logger.Errorf("Skipping custom webhook %s: duplicate name, set a unique name for each webhook", n.name)
+			continue
+		}
+		webhookNames[n.name] = true
+		manager.notifiers = append(manager.notifiers, n)
+	}
+
+	// 按配置替换各渠道的默认消息模板，模板有误的渠道继续使用默认模板
+	for name := range cfg.Notification.Templates.Channels {
+		if _, ok := channelTemplates[name]; !ok && name != "default" {
+			logger.Errorf("Unknown message template channel: %s", name)
+		}
+	}
+	for _, n := range manager.notifiers {
+		if t, ok := n.(templatedNotifier); ok {
+			if err := t.setTemplates(cfg.Notification.Templates); err != nil {
+				logger.Errorf("Invalid message template for %s, using default: %v", n.Name(), err)
+			}
+		}
+		if b, ok := n.(batchedNotifier); ok {
+			b.setBatchOptions(batchOptions(cfg.Notification, n))
+		}
+	}
+
 	return manager
 }
 
-func (nm *NotificationManager) SendAll(ctx context.Context, items []*model.NewsItem) {
+// SetMuteLink 设置生成关键词组静音链接的函数，支持的通知器在消息中附加静音按钮
+func (nm *NotificationManager) SetMuteLink(link func(group string) string) {
+	for _, n := range nm.notifiers {
+		if m, ok := n.(muteLinkNotifier); ok {
+			m.setMuteLink(link)
+		}
+	}
+}
+
+// muteLinkNotifier 可以在消息中附加静音按钮的通知器
+type muteLinkNotifier interface {
+	setMuteLink(link func(group string) string)
+}
+
+// splitList 拆分逗号分隔的配置项，去掉空白和空项
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// batchOptions 返回通知器的分批参数：飞书和钉钉使用各自的分批大小，其他渠道使用 message_batch_size
+func batchOptions(cfg config.NotificationConfig, n Notifier) BatchOptions {
+	opts := BatchOptions{
+		Size:     cfg.MessageBatchSize,
+		Interval: time.Duration(cfg.BatchSendInterval) * time.Second,
+	}
+	if opts.Size <= 0 {
+		opts.Size = DefaultMessageBatchSize
+	}
+	switch n.(type) {
+	case *FeishuNotifier:
+		opts.Size = cfg.FeishuBatchSize
+		if opts.Size <= 0 {
+			opts.Size = DefaultFeishuBatchSize
+		}
+		opts.Separator = cfg.FeishuMessageSeparator
+	case *DingtalkNotifier:
+		opts.Size = cfg.DingtalkBatchSize
+		if opts.Size <= 0 {
+			opts.Size = DefaultDingtalkBatchSize
+		}
+	}
+	return opts
+}
+
+// SendAll 通过所有通知器并行发送，等待全部完成后按通知器的顺序返回各渠道的发送结果
+func (nm *NotificationManager) SendAll(ctx context.Context, items []*model.NewsItem) []model.Delivery {
 	if len(items) == 0 {
-		return
+		return nil
+	}
+
+	deliveries := make([]model.Delivery, len(nm.notifiers))
+	var wg sync.WaitGroup
+	for i, n := range nm.notifiers {
+		wg.Add(1)
+		go func(i int, notifier Notifier) {
+			defer wg.Done()
+			deliveries[i] = deliver(notifier, items, 0)
+		}(i, n)
 	}
+	wg.Wait()
+	return deliveries
+}
 
+// SendTo 通过标识为 channelID 的通知器发送，用于重试发送失败的渠道；该渠道已不在配置中时返回失败
+// 分批发送时跳过前 skip 批，即上次发送中已成功的批次
+func (nm *NotificationManager) SendTo(channelID string, items []*model.NewsItem, skip int) model.Delivery {
 	for _, n := range nm.notifiers {
-		go func(notifier Notifier) {
-			// 为通知发送创建独立的 context，避免因主 context 取消导致通知失败
-			// 设置 30 秒超时，足够发送通知
-			notifyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-			if err := notifier.Send(notifyCtx, items); err != nil {
-				fmt.Printf("Failed to send notification via %s: %v\n", notifier.Name(), err)
-			} else {
-				fmt.Printf("Notification sent via %s\n", notifier.Name())
-			}
-		}(n)
+		if ChannelID(n) == channelID {
+			return deliver(n, items, skip)
+		}
+	}
+	return model.Delivery{Channel: channelID, ChannelID: channelID, Status: model.DeliveryFailed, Error: "channel not configured"}
+}
+
+// ChannelID 返回通知器的渠道标识，如 feishu、webhook:gotify
+// 内置通知器每种只有一个，使用小写的名称；自定义 Webhook 的名称不会重复，以名称区分
+func ChannelID(n Notifier) string {
+	if w, ok := n.(*WebhookNotifier); ok {
+		return "webhook:" + w.name
+	}
+	return strings.ToLower(n.Name())
+}
+
+// deliver 通过一个通知器发送并记录结果，skip 为跳过的批数
+func deliver(notifier Notifier, items []*model.NewsItem, skip int) model.Delivery {
+	// 为通知发送创建独立的 context，避免因主 context 取消导致通知失败
+	// 分批发送时每批之间有间隔，超时时间按 2 分钟设置
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	notifyCtx, sent := withBatchCounter(notifyCtx)
+	if skip > 0 {
+		notifyCtx = withBatchSkip(notifyCtx, skip)
+	}
+
+	start := time.Now()
+	err := notifier.Send(notifyCtx, items)
+	d := model.Delivery{
+		Channel:   notifier.Name(),
+		ChannelID: ChannelID(notifier),
+		Status:    model.DeliverySuccess,
+		Batches:   *sent,
+		Duration:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		d.Status = model.DeliveryFailed
+		d.Error = err.Error()
+	} else if d.Batches == 0 && skip == 0 {
+		d.Batches = 1 // 不分批的通知器只发送一条
 	}
+	return d
 }
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+func TestWebhookName(t *testing.T) {
+	tests := []struct {
+		cfg  config.CustomWebhookConfig
+		want string
+	}{
+		{config.CustomWebhookConfig{Name: "gotify", URL: "https://gotify.example.com/message?token=secret"}, "gotify"},
+		{config.CustomWebhookConfig{URL: "https://sctapi.ftqq.com/SCT123456.send"}, "sctapi.ftqq.com"},
+		{config.CustomWebhookConfig{URL: "http://www.pushplus.plus/send?token=secret"}, "www.pushplus.plus"},
+		{config.CustomWebhookConfig{URL: "{{.Title}}"}, "webhook"},
+	}
+	for _, tt := range tests {
+		if got := webhookName(tt.cfg); got != tt.want {
+			t.Errorf("webhookName(%q) = %q, want %q", tt.cfg.URL, got, tt.want)
+		}
+	}
+}
+
+func TestSendToChannelID(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Notification.CustomWebhooks = []config.CustomWebhookConfig{
+		{Name: "a", URL: server.URL + "/a"},
+		{Name: "b", URL: server.URL + "/b"},
+		{Name: "a", URL: server.URL + "/duplicate"}, // 重名，跳过
+		{URL: server.URL + "/c?token=secret"},       // 未命名，使用主机名
+	}
+	nm := NewNotificationManager(cfg, nil)
+
+	var ids []string
+	for _, n := range nm.notifiers {
+		ids = append(ids, ChannelID(n))
+	}
+	want := []string{"webhook:a", "webhook:b", "webhook:127.0.0.1"}
+	if len(ids) != len(want) {
+		t.Fatalf("channel IDs = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("channel IDs = %v, want %v", ids, want)
+		}
+	}
+
+	items := []*model.NewsItem{{Title: "标题", URL: "https://example.com/1"}}
+	d := nm.SendTo("webhook:b", items, 0)
+	if d.Status != model.DeliverySuccess || d.ChannelID != "webhook:b" || d.Channel != "Webhook(b)" {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+	if hits["/b"] != 1 || hits["/a"] != 0 || hits["/duplicate"] != 0 {
+		t.Errorf("requests = %v, want only /b", hits)
+	}
+
+	if d := nm.SendTo("webhook:missing", items, 0); d.Status != model.DeliveryFailed {
+		t.Errorf("sending to a missing channel should fail, got %+v", d)
+	}
+
+	for _, d := range nm.SendAll(context.Background(), items) {
+		if d.ChannelID == "" || d.Status != model.DeliverySuccess {
+			t.Errorf("unexpected delivery %+v", d)
+		}
+	}
+}
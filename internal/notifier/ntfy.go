@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// NtfyMessageLimit ntfy 单条消息正文的字节数上限，超过时服务器会把正文转为附件，这里拆成多条发送
+const NtfyMessageLimit = 4096
+
+// NtfyOptions ntfy 消息的可选参数
+type NtfyOptions struct {
+	Token    string   // 访问令牌，自建服务开启权限控制时使用
+	Priority int      // 优先级 1-5，0 表示使用服务器默认值（3）
+	Tags     []string // 标签，与 emoji 短代码同名的标签显示为图标，如 newspaper
+	Click    string   // 点击通知时打开的地址
+	Markdown bool     // 正文按 Markdown 渲染（ntfy 网页端和桌面端支持）
+}
+
+type NtfyNotifier struct {
+	batching
+	serverURL string
+	topic     string
+	opts      NtfyOptions
+	tmpl      *MessageTemplate
+}
+
+// NewNtfyNotifier 创建 ntfy 推送通知器
+// serverURL: ntfy 服务器地址，留空使用 https://ntfy.sh
+func NewNtfyNotifier(serverURL, topic string, opts NtfyOptions) *NtfyNotifier {
+	if serverURL == "" {
+		serverURL = "https://ntfy.sh"
+	}
+	return &NtfyNotifier{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		topic:     topic,
+		opts:      opts,
+		tmpl:      defaultMessageTemplate(ntfyTemplate(opts.Markdown)),
+	}
+}
+
+// ntfyTemplate 返回 ntfy 使用的模板渠道名，Markdown 正文使用 ntfy_markdown
+func ntfyTemplate(markdown bool) string {
+	if markdown {
+		return "ntfy_markdown"
+	}
+	return "ntfy"
+}
+
+func (n *NtfyNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate(ntfyTemplate(n.opts.Markdown), cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *NtfyNotifier) Name() string {
+	return "Ntfy"
+}
+
+// NtfyMessage ntfy JSON 发布接口的请求体
+type NtfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Markdown bool     `json:"markdown,omitempty"`
+}
+
+func (n *NtfyNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	if n.topic == "" {
+		return fmt.Errorf("ntfy topic is empty")
+	}
+
+	// 通知标题使用带页码的 header，正文只包含新闻列表
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+	batches := content.batches(n.batch.limit(NtfyMessageLimit), n.batch.Separator)
+	return n.sendBatches(ctx, len(batches), func(i int) error {
+		return n.publish(ctx, NtfyMessage{
+			Topic:    n.topic,
+			Title:    batches[i].Header,
+			Message:  batches[i].Body,
+			Priority: n.opts.Priority,
+			Tags:     n.opts.Tags,
+			Click:    n.opts.Click,
+			Markdown: n.opts.Markdown,
+		})
+	})
+}
+
+func (n *NtfyNotifier) publish(ctx context.Context, msg NtfyMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// JSON 格式发布到服务器根路径，主题写在请求体中
+	req, err := http.NewRequestWithContext(ctx, "POST", n.serverURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.opts.Token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy api status code: %d", resp.StatusCode)
+	}
+	return nil
+}
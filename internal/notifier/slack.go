@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// Slack Block Kit 的消息限制
+const (
+	SlackMaxBlocks    = 50    // 每条消息最多的 block 数
+	SlackHeaderText   = 150   // header block 文本的字符数上限
+	SlackSectionText  = 3000  // section 文本的字符数上限
+	SlackMessageChars = 40000 // 每条消息中文本的字符数上限，超过的部分会被截断
+)
+
+// SlackNotifier 通过 Slack Incoming Webhook 发送 Block Kit 消息，每个平台一个 section
+type SlackNotifier struct {
+	batching
+	webhookURL string
+	tmpl       *MessageTemplate
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: url, tmpl: defaultMessageTemplate("slack")}
+}
+
+func (n *SlackNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("slack", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *SlackNotifier) Name() string {
+	return "Slack"
+}
+
+type SlackText struct {
+	Type string `json:"type"` // plain_text 或 mrkdwn
+	Text string `json:"text"`
+}
+
+type SlackBlock struct {
+	Type string     `json:"type"`
+	Text *SlackText `json:"text,omitempty"`
+}
+
+type SlackMessage struct {
+	Text   string       `json:"text"` // 通知和不支持 Block Kit 的客户端显示的文本
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+func (n *SlackNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+	// header block 只能是纯文本
+	title := content.Header
+
+	var blocks []SlackBlock
+	for _, group := range content.Groups {
+		var lines []string
+		if group.Header != "" {
+			lines = append(lines, group.Header+"\n")
+		}
+		for _, item := range group.Items {
+			lines = append(lines, item+"\n")
+		}
+		for _, chunk := range chunkLinesByChars(lines, SlackSectionText) {
+			blocks = append(blocks, SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: strings.TrimRight(chunk, "\n")}})
+		}
+	}
+	if content.Footer != "" {
+		blocks = append(blocks, SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncateText(SlackSectionText, content.Footer)}})
+	}
+
+	// 第一条消息带标题，block 数或字符数超过限制时拆成多条
+	var messages []SlackMessage
+	msg := SlackMessage{Text: title, Blocks: []SlackBlock{{Type: "header", Text: &SlackText{Type: "plain_text", Text: truncateText(SlackHeaderText, title)}}}}
+	chars := 0
+	for _, block := range blocks {
+		size := utf8.RuneCountInString(block.Text.Text)
+		if len(msg.Blocks) >= SlackMaxBlocks || chars+size > SlackMessageChars {
+			messages = append(messages, msg)
+			msg = SlackMessage{Text: title}
+			chars = 0
+		}
+		msg.Blocks = append(msg.Blocks, block)
+		chars += size
+	}
+	messages = append(messages, msg)
+
+	return n.sendBatches(ctx, len(messages), func(i int) error {
+		msg := messages[i]
+		msg.Text = title + pageMarker(i, len(messages))
+		return n.post(ctx, msg)
+	})
+}
+
+func (n *SlackNotifier) post(ctx context.Context, msg SlackMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 出错时响应体为错误码，如 invalid_blocks、no_service
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack api status code: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// slackEscape 转义 mrkdwn 中的控制字符
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,162 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// TeamsCardLimit 每张 Adaptive Card 中新闻列表编码为 JSON 后的字节数上限
+// Teams 限制消息大小为 28KB，这里为卡片的其他字段留出余量
+const TeamsCardLimit = 20000
+
+// TeamsNotifier 通过 Microsoft Teams 的 Incoming Webhook 或 Workflows 发送 Adaptive Card
+type TeamsNotifier struct {
+	batching
+	webhookURL string
+	tmpl       *MessageTemplate
+}
+
+func NewTeamsNotifier(url string) *TeamsNotifier {
+	return &TeamsNotifier{webhookURL: url, tmpl: defaultMessageTemplate("teams")}
+}
+
+func (n *TeamsNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("teams", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *TeamsNotifier) Name() string {
+	return "Teams"
+}
+
+// TeamsTextBlock Adaptive Card 的 TextBlock 元素
+type TeamsTextBlock struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	Wrap    bool   `json:"wrap"`
+	Size    string `json:"size,omitempty"`
+	Weight  string `json:"weight,omitempty"`
+	Spacing string `json:"spacing,omitempty"`
+}
+
+type TeamsCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []TeamsTextBlock `json:"body"`
+}
+
+type TeamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     TeamsCard `json:"content"`
+}
+
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+func (n *TeamsNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
+	title := content.Header
+
+	// 每个分组一个标题 TextBlock 和一个新闻列表 TextBlock，按大小分到多张卡片中
+	var cards [][]TeamsTextBlock
+	var body []TeamsTextBlock
+	size := 0
+	for _, group := range content.Groups {
+		var lines []string
+		for _, item := range group.Items {
+			// TextBlock 中单个换行不分段
+			lines = append(lines, item+"\n\n")
+		}
+		for _, chunk := range chunkLines(lines, TeamsCardLimit) {
+			chunkSize := jsonSize(chunk)
+			if size > 0 && size+chunkSize > TeamsCardLimit {
+				cards = append(cards, body)
+				body, size = nil, 0
+			}
+			if group.Header != "" {
+				body = append(body, TeamsTextBlock{Type: "TextBlock", Text: group.Header, Wrap: true, Weight: "Bolder", Spacing: "Medium"})
+			}
+			body = append(body, TeamsTextBlock{Type: "TextBlock", Text: strings.TrimRight(chunk, "\n"), Wrap: true, Spacing: "Small"})
+			size += chunkSize
+		}
+	}
+	if content.Footer != "" {
+		body = append(body, TeamsTextBlock{Type: "TextBlock", Text: content.Footer, Wrap: true, Spacing: "Medium"})
+	}
+	cards = append(cards, body)
+
+	return n.sendBatches(ctx, len(cards), func(i int) error {
+		cardTitle := title + pageMarker(i, len(cards))
+		card := TeamsCard{
+			Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+			Type:    "AdaptiveCard",
+			Version: "1.4",
+			Body:    append([]TeamsTextBlock{{Type: "TextBlock", Text: cardTitle, Wrap: true, Size: "Large", Weight: "Bolder"}}, cards[i]...),
+		}
+		return n.post(ctx, TeamsMessage{
+			Type:        "message",
+			Attachments: []TeamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}},
+		})
+	})
+}
+
+func (n *TeamsNotifier) post(ctx context.Context, msg TeamsMessage) error {
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false) // 链接中的 & 不转义为 \u0026，减小消息体积
+	if err := enc.Encode(msg); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, &data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Workflows 成功时返回 202；旧版 Incoming Webhook 返回 200，响应体为 1，出错时状态码仍可能为 200，响应体为错误信息
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	text := strings.TrimSpace(string(body))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("teams api status code: %d %s", resp.StatusCode, text)
+	}
+	if resp.StatusCode == http.StatusOK && text != "" && text != "1" {
+		return fmt.Errorf("teams api error: %s", text)
+	}
+	return nil
+}
+
+// jsonSize 返回字符串编码为 JSON 字符串后的字节数
+func jsonSize(s string) int {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return buf.Len()
+}
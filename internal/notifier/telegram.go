@@ -5,23 +5,86 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
+// TelegramMessageLimit Telegram 单条消息的长度上限
+const TelegramMessageLimit = 4096
+
+// Telegram 消息的格式
+const (
+	TelegramParseModeHTML       = "HTML" // 默认
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+)
+
+// DefaultTelegramAPIBaseURL 官方 Bot API 的地址
+const DefaultTelegramAPIBaseURL = "https://api.telegram.org"
+
+// TelegramMuteButtons 消息下方最多显示的静音关键词组按钮数
+const TelegramMuteButtons = 3
+
+// TelegramOptions Telegram 消息的可选参数
+type TelegramOptions struct {
+	ParseMode  string // HTML 或 MarkdownV2，留空使用 HTML
+	APIBaseURL string // Bot API 地址，自建 Bot API 服务器时使用，留空使用官方地址
+	ThreadID   int    // 超级群组的话题 ID，0 表示不指定
+	Buttons    bool   // 在最后一条消息下方显示按钮
+	OpenURL    string // “打开 TrendHub”按钮的地址，为空时不显示
+}
+
 type TelegramNotifier struct {
+	batching
 	botToken string
 	chatID   string
+	opts     TelegramOptions
+	muteLink func(group string) string
+	tmpl     *MessageTemplate
 }
 
-func NewTelegramNotifier(token, chatID string) *TelegramNotifier {
+// NewTelegramNotifier 创建 Telegram 机器人通知器
+func NewTelegramNotifier(token, chatID string, opts TelegramOptions) *TelegramNotifier {
+	if strings.EqualFold(strings.TrimSpace(opts.ParseMode), TelegramParseModeMarkdownV2) {
+		opts.ParseMode = TelegramParseModeMarkdownV2
+	} else {
+		opts.ParseMode = TelegramParseModeHTML
+	}
+	opts.APIBaseURL = strings.TrimRight(strings.TrimSpace(opts.APIBaseURL), "/")
+	if opts.APIBaseURL == "" {
+		opts.APIBaseURL = DefaultTelegramAPIBaseURL
+	}
 	return &TelegramNotifier{
 		botToken: token,
 		chatID:   chatID,
+		opts:     opts,
+		tmpl:     defaultMessageTemplate(telegramTemplate(opts.ParseMode)),
+	}
+}
+
+// telegramTemplate 返回 Telegram 使用的模板渠道名，MarkdownV2 使用 telegram_markdownv2
+func telegramTemplate(parseMode string) string {
+	if parseMode == TelegramParseModeMarkdownV2 {
+		return "telegram_markdownv2"
 	}
+	return "telegram"
+}
+
+func (n *TelegramNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate(telegramTemplate(n.opts.ParseMode), cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *TelegramNotifier) setMuteLink(link func(group string) string) {
+	n.muteLink = link
 }
 
 func (n *TelegramNotifier) Name() string {
@@ -29,46 +92,98 @@ func (n *TelegramNotifier) Name() string {
 }
 
 type TelegramMessage struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode"`
+	ChatID             string                        `json:"chat_id"`
+	MessageThreadID    int                           `json:"message_thread_id,omitempty"`
+	Text               string                        `json:"text"`
+	ParseMode          string                        `json:"parse_mode"`
+	LinkPreviewOptions *TelegramLinkPreviewOptions   `json:"link_preview_options,omitempty"`
+	ReplyMarkup        *TelegramInlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type TelegramLinkPreviewOptions struct {
+	IsDisabled bool `json:"is_disabled"`
+}
+
+type TelegramInlineKeyboardMarkup struct {
+	InlineKeyboard [][]TelegramInlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type TelegramInlineKeyboardButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// telegramResponse Bot API 的响应，出错时 ok 为 false，description 说明原因
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
 }
 
 func (n *TelegramNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("<b>TrendHub 热点监控报告</b> (%s)\n", time.Now().Format("15:04")))
+	// 默认模板中的 escape、escapeURL 按消息格式转义标题和链接
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
+	}
 
-	currentSource := ""
-	for _, item := range items {
-		if item.SourceName != currentSource {
-			sb.WriteString(fmt.Sprintf("\n<b>%s</b>\n", item.SourceName))
-			currentSource = item.SourceName
+	// 按 message_batch_size 分批，只在新闻之间拆分，不会拆开 HTML 标签或 MarkdownV2 实体
+	batches := content.batches(n.batch.limit(TelegramMessageLimit), n.batch.Separator)
+	keyboard := n.keyboard(items)
+	return n.sendBatches(ctx, len(batches), func(i int) error {
+		msg := TelegramMessage{
+			ChatID:             n.chatID,
+			MessageThreadID:    n.opts.ThreadID,
+			Text:               batches[i].text(),
+			ParseMode:          n.opts.ParseMode,
+			LinkPreviewOptions: &TelegramLinkPreviewOptions{IsDisabled: true},
 		}
-		
-		title := item.Title
-		// Telegram HTML mode 特殊字符转义需注意，这里简化处理
-		title = strings.ReplaceAll(title, "<", "&lt;")
-		title = strings.ReplaceAll(title, ">", "&gt;")
-
-		if item.URL != "" {
-			sb.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a>\n", item.Ranks[0], item.URL, title))
-		} else {
-			sb.WriteString(fmt.Sprintf("%d. %s\n", item.Ranks[0], title))
+		if i == len(batches)-1 {
+			msg.ReplyMarkup = keyboard
 		}
-	}
+		return n.post(ctx, msg)
+	})
+}
 
-	msg := TelegramMessage{
-		ChatID:    n.chatID,
-		Text:      sb.String(),
-		ParseMode: "HTML",
+// keyboard 返回消息下方的按钮：“打开 TrendHub”和排名靠前的关键词组的静音按钮，没有按钮时为 nil
+func (n *TelegramNotifier) keyboard(items []*model.NewsItem) *TelegramInlineKeyboardMarkup {
+	if !n.opts.Buttons {
+		return nil
+	}
+	var rows [][]TelegramInlineKeyboardButton
+	if n.opts.OpenURL != "" {
+		rows = append(rows, []TelegramInlineKeyboardButton{{Text: "打开 TrendHub", URL: n.opts.OpenURL}})
 	}
+	if n.muteLink != nil {
+		seen := make(map[string]bool)
+		for _, item := range items {
+			group := item.KeywordGroupKey
+			if group == "" || seen[group] {
+				continue
+			}
+			if len(seen) == TelegramMuteButtons {
+				break
+			}
+			seen[group] = true
+			rows = append(rows, []TelegramInlineKeyboardButton{{Text: "🔕 静音「" + truncateText(20, group) + "」", URL: n.muteLink(group)}})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &TelegramInlineKeyboardMarkup{InlineKeyboard: rows}
+}
 
+func (n *TelegramNotifier) post(ctx context.Context, msg TelegramMessage) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.botToken)
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.opts.APIBaseURL, n.botToken)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
 	if err != nil {
 		return err
@@ -82,10 +197,34 @@ func (n *TelegramNotifier) Send(ctx context.Context, items []*model.NewsItem) er
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram api status code: %d", resp.StatusCode)
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var result telegramResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("telegram api status code: %d", resp.StatusCode)
+		}
+		return fmt.Errorf("decode telegram response failed: %w", err)
+	}
+	if !result.OK {
+		// 常见错误：400 can't parse entities（自定义模板未正确转义），403 机器人被移出群组，429 发送太频繁
+		if result.Parameters.RetryAfter > 0 {
+			return fmt.Errorf("telegram api error %d: %s (retry after %ds)", result.ErrorCode, result.Description, result.Parameters.RetryAfter)
+		}
+		return fmt.Errorf("telegram api error %d: %s", result.ErrorCode, result.Description)
 	}
 
 	return nil
 }
 
+// markdownV2Escape 转义 MarkdownV2 中所有有特殊含义的字符
+func markdownV2Escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
@@ -0,0 +1,420 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// 消息的分组方式
+const (
+	GroupBySource  = "source"  // 按平台分组，默认
+	GroupByKeyword = "keyword" // 按匹配的关键词组分组
+)
+
+// 消息模板由以下几部分组成，通知器负责把它们组装成各平台的消息格式并按长度拆分
+const (
+	partTitle  = "title"  // 标题，用作通知标题、邮件主题等
+	partHeader = "header" // 正文开头
+	partGroup  = "group"  // 分组标题
+	partItem   = "item"   // 一条新闻
+	partFooter = "footer" // 正文结尾
+)
+
+var templateParts = []string{partTitle, partHeader, partGroup, partItem, partFooter}
+
+// 模板的格式，决定 escape 函数的转义方式
+const (
+	formatText       = "text"
+	formatMarkdown   = "markdown"
+	formatHTML       = "html"
+	formatSlack      = "slack"
+	formatLarkMD     = "lark_md"    // 飞书消息卡片
+	formatMarkdownV2 = "markdownv2" // Telegram MarkdownV2
+)
+
+// MessageData title、header、footer 模板的数据
+type MessageData struct {
+	Title   string            // title 模板的输出，header、footer 中可用 {{escape .Title}} 插入转义后的标题
+	Time    time.Time         // 发送时间
+	Count   int               // 本次推送的新闻条数
+	Omitted int               // 因渠道限制未显示的新闻条数
+	Items   []*model.NewsItem // 排序后的新闻
+	Groups  []MessageGroup    // 分组后的新闻
+}
+
+// MessageGroup group 模板的数据
+type MessageGroup struct {
+	Name  string // 平台名称或关键词组
+	Index int    // 分组序号，从1开始
+	Items []*model.NewsItem
+}
+
+// MessageItem item 模板的数据，可以直接使用新闻的字段，如 .Title、.URL、.SourceName
+type MessageItem struct {
+	*model.NewsItem
+	Index int    // 在分组中的序号，从1开始
+	Rank  int    // 在平台榜单上的排名
+	Group string // 所在分组的名称
+}
+
+// baseTemplate 所有渠道共用的纯文本模板，各渠道的默认模板在此基础上覆盖
+var baseTemplate = map[string]string{
+	partTitle:  `TrendHub 热点监控报告`,
+	partHeader: `{{template "title" .}} ({{.Time.Format "2006-01-02 15:04"}})`,
+	partGroup:  `【{{.Name}}】`,
+	partItem:   "{{.Rank}}. {{.Title}}{{if .URL}}\n   {{.URL}}{{end}}",
+	partFooter: ``,
+}
+
+const markdownItem = `{{.Rank}}. {{if .URL}}[{{escape .Title}}]({{.URL}}){{else}}{{.Title}}{{end}}`
+
+// channelTemplates 各渠道的默认模板，键为配置中 templates.channels 使用的渠道名
+var channelTemplates = map[string]struct {
+	format string
+	parts  map[string]string
+}{
+	"feishu": {formatText, nil},
+	"feishu_card": {formatLarkMD, map[string]string{
+		partHeader: `{{template "title" .}} ({{.Time.Format "2006-01-02 15:04"}})`,
+		partGroup:  `**{{escape .Name}}**`,
+		partItem:   `{{.Rank}}. {{if .URL}}[{{escape .Title}}]({{.URL}}){{else}}{{escape .Title}}{{end}}{{range .MatchedKeywords}} <font color='grey'>#{{escape .}}</font>{{end}}`,
+	}},
+	"wps": {formatText, nil},
+	"dingtalk": {formatMarkdown, map[string]string{
+		partHeader: `# {{template "title" .}} ({{.Time.Format "15:04"}})`,
+		partGroup:  `## {{.Name}}`,
+		partItem:   `- **{{.Rank}}.** {{if .URL}}[{{escape .Title}}]({{.URL}}){{else}}{{.Title}}{{end}}`,
+	}},
+	"wework": {formatMarkdown, map[string]string{
+		partHeader: `## {{template "title" .}} ({{.Time.Format "15:04"}})`,
+		partGroup:  `**{{.Name}}**`,
+		partItem:   markdownItem,
+	}},
+	"telegram": {formatHTML, map[string]string{
+		partHeader: `<b>{{escape .Title}}</b> ({{.Time.Format "15:04"}})`,
+		partGroup:  `<b>{{escape .Name}}</b>`,
+		partItem:   `{{.Rank}}. {{if .URL}}<a href="{{escapeURL .URL}}">{{escape .Title}}</a>{{else}}{{escape .Title}}{{end}}`,
+	}},
+	"telegram_markdownv2": {formatMarkdownV2, map[string]string{
+		partHeader: `*{{escape .Title}}* \({{.Time.Format "15:04"}}\)`,
+		partGroup:  `*{{escape .Name}}*`,
+		partItem:   `{{.Rank}}\. {{if .URL}}[{{escape .Title}}]({{escapeURL .URL}}){{else}}{{escape .Title}}{{end}}`,
+	}},
+	"slack": {formatSlack, map[string]string{
+		partHeader: `{{template "title" .}} ({{.Time.Format "15:04"}})`,
+		partGroup:  `*{{escape .Name}}*`,
+		partItem:   `{{.Rank}}. {{if .URL}}<{{.URL}}|{{escape .Title}}>{{else}}{{escape .Title}}{{end}}`,
+	}},
+	"discord": {formatMarkdown, map[string]string{
+		partHeader: `**{{template "title" .}}** ({{.Time.Format "15:04"}})`,
+		partGroup:  `{{.Name}}`,
+		partItem:   `**{{.Rank}}.** {{if .URL}}[{{escape .Title}}]({{.URL}}){{else}}{{.Title}}{{end}}`,
+	}},
+	"teams": {formatMarkdown, map[string]string{
+		partHeader: `{{template "title" .}} ({{.Time.Format "15:04"}})`,
+		partGroup:  `{{.Name}}`,
+		partItem:   `**{{.Rank}}.** {{if .URL}}[{{escape .Title}}]({{.URL}}){{else}}{{.Title}}{{end}}`,
+	}},
+	"bark": {formatText, map[string]string{
+		partHeader: `热点监控 ({{.Time.Format "15:04"}})`,
+		partItem:   `{{.Rank}}. {{.Title}}`,
+		partFooter: `{{if .Omitted}}...还有 {{.Omitted}} 条{{end}}`,
+	}},
+	"ntfy": {formatText, map[string]string{
+		partHeader: `{{template "title" .}} ({{.Time.Format "15:04"}})`,
+	}},
+	"ntfy_markdown": {formatMarkdown, map[string]string{
+		partHeader: `{{template "title" .}} ({{.Time.Format "15:04"}})`,
+		partGroup:  `**{{.Name}}**`,
+		partItem:   markdownItem,
+	}},
+	"email": {formatText, nil},
+	"email_html": {formatHTML, map[string]string{
+		partHeader: `<h2 style="margin-bottom: 4px;">{{template "title" .}}</h2><div style="color: #6b7280;">{{.Time.Format "2006-01-02 15:04"}}</div>`,
+		partGroup:  `{{escape .Name}}`,
+		partItem:   `<span style="color: #9ca3af;">{{.Rank}}.</span> {{if .URL}}<a href="{{escape .URL}}" style="color: #1f2937;">{{escape .Title}}</a>{{else}}{{escape .Title}}{{end}}`,
+	}},
+	"webhook": {formatText, nil},
+	"webhook_markdown": {formatMarkdown, map[string]string{
+		partHeader: `## {{template "title" .}} ({{.Time.Format "2006-01-02 15:04"}})`,
+		partGroup:  `**{{.Name}}**`,
+		partItem:   markdownItem,
+	}},
+}
+
+// templateFuncs 模板中可以使用的函数，另有 text/template 内置的 urlquery、printf、len、index 等
+func templateFuncs(format string) template.FuncMap {
+	return template.FuncMap{
+		// escape 按渠道的消息格式转义文本
+		"escape": escaper(format),
+		// escapeURL 按渠道的消息格式转义链接地址，用于 HTML 的 href 和 MarkdownV2 链接的括号中
+		"escapeURL": urlEscaper(format),
+		// json 将值编码为 JSON，字符串会带上引号并转义，用于在 JSON 请求体中安全地嵌入文本
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		// rank 返回新闻在平台榜单上的排名
+		"rank": itemRank,
+		// truncate 按字符数截断文本，超出时以 … 结尾
+		"truncate": truncateText,
+		// ago 返回相对于现在的时间，如“5 分钟前”
+		"ago": relativeTime,
+		// groupBySource、groupByKeyword 对新闻分组，用于在 header、footer 中自行组织内容
+		"groupBySource":  func(items []*model.NewsItem) []MessageGroup { return groupItems(items, GroupBySource) },
+		"groupByKeyword": func(items []*model.NewsItem) []MessageGroup { return groupItems(items, GroupByKeyword) },
+		"add":            func(a, b int) int { return a + b },
+	}
+}
+
+func escaper(format string) func(string) string {
+	switch format {
+	case formatMarkdown:
+		return escapeMarkdownLink
+	case formatHTML:
+		return html.EscapeString
+	case formatSlack:
+		return slackEscape
+	case formatLarkMD:
+		return larkMDEscape
+	case formatMarkdownV2:
+		return markdownV2Escape
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+func urlEscaper(format string) func(string) string {
+	switch format {
+	case formatHTML:
+		return html.EscapeString
+	case formatMarkdownV2:
+		return strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+func truncateText(n int, s string) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func relativeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "刚刚"
+	case d < time.Hour:
+		return fmt.Sprintf("%d 分钟前", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d 小时前", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d 天前", int(d.Hours()/24))
+	}
+}
+
+// groupItems 对新闻分组，分组按首次出现的顺序排列，组内保持原有顺序
+// 按关键词组分组时，未匹配关键词组的新闻归入“其他”并排在最后
+func groupItems(items []*model.NewsItem, groupBy string) []MessageGroup {
+	var groups []MessageGroup
+	if groupBy == GroupByKeyword {
+		index := make(map[string]int)
+		for _, item := range items {
+			name := item.KeywordGroupKey
+			if name == "" {
+				name = "其他"
+			}
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name] = i
+				groups = append(groups, MessageGroup{Name: name})
+			}
+			groups[i].Items = append(groups[i].Items, item)
+		}
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i].Name != "其他" && groups[j].Name == "其他"
+		})
+	} else {
+		for _, group := range groupBySource(items) {
+			groups = append(groups, MessageGroup{Name: group.Name, Items: group.Items})
+		}
+	}
+	for i := range groups {
+		groups[i].Index = i + 1
+	}
+	return groups
+}
+
+// MessageTemplate 一个渠道的消息模板
+type MessageTemplate struct {
+	tmpl    *template.Template
+	groupBy string
+	escape  func(string) string
+}
+
+// newMessageTemplate 按渠道的默认模板和配置中的覆盖创建消息模板
+// 覆盖的顺序为：所有渠道共用的纯文本模板、渠道默认模板、templates.channels.default、templates.channels.<渠道名>
+func newMessageTemplate(channel string, cfg config.TemplatesConfig) (*MessageTemplate, error) {
+	defaults, ok := channelTemplates[channel]
+	if !ok {
+		return nil, fmt.Errorf("unknown template channel %q", channel)
+	}
+	groupBy := strings.ToLower(strings.TrimSpace(cfg.GroupBy))
+	switch groupBy {
+	case "":
+		groupBy = GroupBySource
+	case GroupBySource, GroupByKeyword:
+	default:
+		return nil, fmt.Errorf("invalid group_by %q, must be %s or %s", cfg.GroupBy, GroupBySource, GroupByKeyword)
+	}
+
+	parts := make(map[string]string)
+	for _, layer := range []map[string]string{baseTemplate, defaults.parts, cfg.Channels["default"], cfg.Channels[channel]} {
+		for name, text := range layer {
+			if _, ok := baseTemplate[name]; !ok {
+				return nil, fmt.Errorf("unknown template part %q, must be one of %s", name, strings.Join(templateParts, ", "))
+			}
+			parts[name] = text
+		}
+	}
+
+	root := template.New(channel).Funcs(templateFuncs(defaults.format))
+	for _, name := range templateParts {
+		if _, err := root.New(name).Parse(parts[name]); err != nil {
+			return nil, fmt.Errorf("template %s.%s: %w", channel, name, err)
+		}
+	}
+
+	t := &MessageTemplate{tmpl: root, groupBy: groupBy, escape: escaper(defaults.format)}
+	// 用示例数据渲染一次，提前发现引用了不存在的字段等错误
+	sample := &model.NewsItem{Title: "示例新闻", URL: "https://example.com", Ranks: []int{1}, SourceName: "示例平台", FirstSeenAt: time.Now()}
+	if _, err := t.render(&MessageData{Time: time.Now(), Items: []*model.NewsItem{sample}}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// defaultMessageTemplate 返回渠道的默认模板，供通知器在构造时使用
+func defaultMessageTemplate(channel string) *MessageTemplate {
+	t, err := newMessageTemplate(channel, config.TemplatesConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// renderedMessage 按模板生成的消息各部分，由通知器组装成各平台的消息格式
+type renderedMessage struct {
+	Title  string
+	Header string
+	Groups []renderedGroup
+	Footer string
+
+	escape func(string) string // 渠道格式的转义函数，用于拆分时附加的页码
+}
+
+type renderedGroup struct {
+	Name   string // 分组名称，未经模板处理
+	Header string
+	Items  []string
+}
+
+// render 生成消息各部分，data 中的 Count 和 Groups 由模板填充
+func (t *MessageTemplate) render(data *MessageData) (*renderedMessage, error) {
+	data.Count = len(data.Items)
+	data.Groups = groupItems(data.Items, t.groupBy)
+
+	var err error
+	msg := &renderedMessage{escape: t.escape}
+	if msg.Title, err = t.execute(partTitle, data); err != nil {
+		return nil, err
+	}
+	data.Title = msg.Title
+	if msg.Header, err = t.execute(partHeader, data); err != nil {
+		return nil, err
+	}
+	for _, group := range data.Groups {
+		rg := renderedGroup{Name: group.Name}
+		if rg.Header, err = t.execute(partGroup, group); err != nil {
+			return nil, err
+		}
+		for i, item := range group.Items {
+			line, err := t.execute(partItem, MessageItem{NewsItem: item, Index: i + 1, Rank: itemRank(item), Group: group.Name})
+			if err != nil {
+				return nil, err
+			}
+			rg.Items = append(rg.Items, line)
+		}
+		msg.Groups = append(msg.Groups, rg)
+	}
+	if msg.Footer, err = t.execute(partFooter, data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (t *MessageTemplate) execute(name string, data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+	// YAML 的多行字符串会带上结尾的换行，行与行之间的换行由通知器添加
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// lines 把消息展开为以换行结尾的行，用于按长度拆分
+// 分组之间空一行，分组标题与该组第一条新闻在同一行中，拆分时不会与新闻分开
+func (m *renderedMessage) lines(withHeader bool) []string {
+	var lines []string
+	if withHeader && m.Header != "" {
+		lines = append(lines, m.Header+"\n")
+	}
+	for _, group := range m.Groups {
+		prefix := ""
+		if len(lines) > 0 {
+			prefix = "\n"
+		}
+		if group.Header != "" {
+			prefix += group.Header + "\n"
+		}
+		for i, item := range group.Items {
+			if i == 0 {
+				item = prefix + item
+			}
+			lines = append(lines, item+"\n")
+		}
+	}
+	if m.Footer != "" {
+		lines = append(lines, "\n"+m.Footer+"\n")
+	}
+	return lines
+}
+
+// text 返回完整的消息正文
+func (m *renderedMessage) text() string {
+	return strings.Join(m.lines(true), "")
+}
+
+// templatedNotifier 使用消息模板的通知器，由 NotificationManager 按配置替换默认模板
+type templatedNotifier interface {
+	setTemplates(cfg config.TemplatesConfig) error
+}
@@ -0,0 +1,187 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// DefaultWebhookTemplate 未配置模板时使用的请求体
+const DefaultWebhookTemplate = `{"title": {{json .Title}}, "text": {{json .Text}}}`
+
+// WebhookData 自定义 Webhook 模板可以使用的数据
+type WebhookData struct {
+	Title    string            // 报告标题，如“TrendHub 热点监控报告”
+	Time     time.Time         // 发送时间
+	Count    int               // 新闻条数
+	Items    []*model.NewsItem // 排序后的新闻
+	Groups   []WebhookGroup    // 分组后的新闻，分组方式由 templates.group_by 决定
+	Text     string            // 由 webhook 消息模板生成的纯文本摘要
+	Markdown string            // 由 webhook_markdown 消息模板生成的 Markdown 摘要
+}
+
+// WebhookGroup 同一分组的新闻
+type WebhookGroup struct {
+	Name  string
+	Items []*model.NewsItem
+}
+
+// WebhookNotifier 按模板生成请求，发送到任意地址，用于对接内部系统或没有专用通知器的推送服务
+type WebhookNotifier struct {
+	name        string
+	method      string
+	contentType string
+	headers     map[string]string
+	url         *template.Template
+	body        *template.Template
+	text        *MessageTemplate
+	markdown    *MessageTemplate
+}
+
+// NewWebhookNotifier 创建自定义 Webhook 通知器，地址或模板有语法错误时返回错误
+func NewWebhookNotifier(cfg config.CustomWebhookConfig) (*WebhookNotifier, error) {
+	name := webhookName(cfg)
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook %s: url is empty", name)
+	}
+
+	urlTmpl, err := template.New("url").Funcs(templateFuncs(formatText)).Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: invalid url template: %w", name, err)
+	}
+	body := cfg.Template
+	if strings.TrimSpace(body) == "" {
+		body = DefaultWebhookTemplate
+	}
+	bodyTmpl, err := template.New("body").Funcs(templateFuncs(formatText)).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: invalid template: %w", name, err)
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(cfg.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	contentType := cfg.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	return &WebhookNotifier{
+		name:        name,
+		method:      method,
+		contentType: contentType,
+		headers:     cfg.Headers,
+		url:         urlTmpl,
+		body:        bodyTmpl,
+		text:        defaultMessageTemplate("webhook"),
+		markdown:    defaultMessageTemplate("webhook_markdown"),
+	}, nil
+}
+
+// webhookName 返回 Webhook 的名称，未配置时使用请求地址的主机名
+// 名称会出现在日志和推送记录中，不能直接使用请求地址：Server酱、PushPlus 等服务把密钥放在地址中
+func webhookName(cfg config.CustomWebhookConfig) string {
+	if name := strings.TrimSpace(cfg.Name); name != "" {
+		return name
+	}
+	if u, err := url.Parse(strings.TrimSpace(cfg.URL)); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "webhook"
+}
+
+func (n *WebhookNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	text, err := newMessageTemplate("webhook", cfg)
+	if err != nil {
+		return err
+	}
+	markdown, err := newMessageTemplate("webhook_markdown", cfg)
+	if err != nil {
+		return err
+	}
+	n.text, n.markdown = text, markdown
+	return nil
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "Webhook(" + n.name + ")"
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	data, err := n.newWebhookData(items, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var target strings.Builder
+	if err := n.url.Execute(&target, data); err != nil {
+		return fmt.Errorf("render url: %w", err)
+	}
+	var body bytes.Buffer
+	if err := n.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("render template: %w", err)
+	}
+
+	// GET 和 HEAD 请求不带请求体，数据通过地址模板传递
+	var reader io.Reader = &body
+	if n.method == http.MethodGet || n.method == http.MethodHead {
+		reader = nil
+	}
+	req, err := http.NewRequestWithContext(ctx, n.method, strings.TrimSpace(target.String()), reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", n.contentType)
+	}
+	for key, value := range n.headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook status code: %d %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) newWebhookData(items []*model.NewsItem, now time.Time) (*WebhookData, error) {
+	text, err := n.text.render(&MessageData{Time: now, Items: items})
+	if err != nil {
+		return nil, err
+	}
+	md, err := n.markdown.render(&MessageData{Time: now, Items: items})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &WebhookData{
+		Title:    text.Title,
+		Time:     now,
+		Count:    len(items),
+		Items:    items,
+		Text:     text.text(),
+		Markdown: md.text(),
+	}
+	for _, group := range groupItems(items, n.text.groupBy) {
+		data.Groups = append(data.Groups, WebhookGroup{Name: group.Name, Items: group.Items})
+	}
+	return data, nil
+}
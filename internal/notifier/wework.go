@@ -0,0 +1,190 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// 企业微信群机器人的消息类型
+const (
+	WeworkMsgTypeMarkdown = "markdown" // Markdown 文本，默认
+	WeworkMsgTypeNews     = "news"     // 图文卡片，每条新闻一张卡片
+)
+
+// 企业微信群机器人的消息限制
+const (
+	WeworkMarkdownLimit = 4096 // markdown 内容的字节数上限
+	WeworkNewsLimit     = 8    // 每条图文消息最多的卡片数
+)
+
+type WeworkNotifier struct {
+	batching
+	webhookURL string
+	msgType    string
+	tmpl       *MessageTemplate
+}
+
+// NewWeworkNotifier 创建企业微信群机器人通知器
+// msgType: markdown 或 news，留空使用 markdown
+func NewWeworkNotifier(url, msgType string) *WeworkNotifier {
+	msgType = strings.ToLower(strings.TrimSpace(msgType))
+	if msgType != WeworkMsgTypeNews {
+		msgType = WeworkMsgTypeMarkdown
+	}
+	return &WeworkNotifier{webhookURL: url, msgType: msgType, tmpl: defaultMessageTemplate("wework")}
+}
+
+func (n *WeworkNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("wework", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
+func (n *WeworkNotifier) Name() string {
+	return "Wework"
+}
+
+// WeworkArticle 图文消息中的一张卡片
+type WeworkArticle struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+	PicURL      string `json:"picurl,omitempty"`
+}
+
+type WeworkMessage struct {
+	MsgType  string          `json:"msgtype"`
+	Markdown *WeworkMarkdown `json:"markdown,omitempty"`
+	News     *WeworkNews     `json:"news,omitempty"`
+}
+
+type WeworkMarkdown struct {
+	Content string `json:"content"`
+}
+
+type WeworkNews struct {
+	Articles []WeworkArticle `json:"articles"`
+}
+
+// weworkResponse 企业微信接口的响应，HTTP 状态码为200时也可能通过 errcode 返回错误
+type weworkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (n *WeworkNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
+	var messages []WeworkMessage
+	var err error
+	if n.msgType == WeworkMsgTypeNews {
+		messages, err = n.newsMessages(items)
+	} else {
+		messages, err = n.markdownMessages(items)
+	}
+	if err != nil {
+		return err
+	}
+
+	return n.sendBatches(ctx, len(messages), func(i int) error {
+		return n.post(ctx, messages[i])
+	})
+}
+
+// markdownMessages 生成 markdown 消息，按 message_batch_size（不超过 4096 字节）拆成多条
+func (n *WeworkNotifier) markdownMessages(items []*model.NewsItem) ([]WeworkMessage, error) {
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []WeworkMessage
+	for _, batch := range content.batches(n.batch.limit(WeworkMarkdownLimit), n.batch.Separator) {
+		messages = append(messages, WeworkMessage{
+			MsgType:  WeworkMsgTypeMarkdown,
+			Markdown: &WeworkMarkdown{Content: batch.text()},
+		})
+	}
+	return messages, nil
+}
+
+// newsMessages 生成图文消息，每条最多8张卡片；没有链接的新闻无法生成卡片，改用 markdown 发送
+// 卡片的标题和描述由企业微信固定展示，不使用消息模板
+func (n *WeworkNotifier) newsMessages(items []*model.NewsItem) ([]WeworkMessage, error) {
+	var articles []WeworkArticle
+	var noLink []*model.NewsItem
+	for _, item := range items {
+		link := newsLink(item)
+		if link == "" {
+			noLink = append(noLink, item)
+			continue
+		}
+		articles = append(articles, WeworkArticle{
+			Title:       item.Title,
+			Description: fmt.Sprintf("%s 第%d名", item.SourceName, itemRank(item)),
+			URL:         link,
+		})
+	}
+
+	var messages []WeworkMessage
+	for start := 0; start < len(articles); start += WeworkNewsLimit {
+		end := start + WeworkNewsLimit
+		if end > len(articles) {
+			end = len(articles)
+		}
+		messages = append(messages, WeworkMessage{
+			MsgType: WeworkMsgTypeNews,
+			News:    &WeworkNews{Articles: articles[start:end]},
+		})
+	}
+	if len(noLink) > 0 {
+		markdown, err := n.markdownMessages(noLink)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, markdown...)
+	}
+	return messages, nil
+}
+
+func (n *WeworkNotifier) post(ctx context.Context, msg WeworkMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.webhookURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wework api status code: %d", resp.StatusCode)
+	}
+
+	var result weworkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode wework response failed: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("wework api error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
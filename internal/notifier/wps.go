@@ -6,22 +6,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
 type WPSNotifier struct {
+	batching
 	webhookURL string
+	tmpl       *MessageTemplate
 }
 
 func NewWPSNotifier(url string) *WPSNotifier {
 	return &WPSNotifier{
 		webhookURL: url,
+		tmpl:       defaultMessageTemplate("wps"),
 	}
 }
 
+func (n *WPSNotifier) setTemplates(cfg config.TemplatesConfig) error {
+	t, err := newMessageTemplate("wps", cfg)
+	if err != nil {
+		return err
+	}
+	n.tmpl = t
+	return nil
+}
+
 func (n *WPSNotifier) Name() string {
 	return "WPS"
 }
@@ -34,27 +46,23 @@ type WPSMessage struct {
 }
 
 func (n *WPSNotifier) Send(ctx context.Context, items []*model.NewsItem) error {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("TrendHub 热点监控报告 (%s)\n\n", time.Now().Format("2006-01-02 15:04")))
-
-	// 简单的文本格式化
-	currentSource := ""
-	for _, item := range items {
-		if item.SourceName != currentSource {
-			sb.WriteString(fmt.Sprintf("\n【%s】\n", item.SourceName))
-			currentSource = item.SourceName
-		}
-		sb.WriteString(fmt.Sprintf("%d. %s\n", item.Ranks[0], item.Title))
-		if item.URL != "" {
-			sb.WriteString(fmt.Sprintf("   %s\n", item.URL))
-		}
+	content, err := n.tmpl.render(&MessageData{Time: time.Now(), Items: items})
+	if err != nil {
+		return err
 	}
 
-	msg := WPSMessage{
-		MsgType: "text",
-	}
-	msg.Text.Content = sb.String()
+	// 按 message_batch_size 分批
+	batches := content.batches(n.batch.limit(0), n.batch.Separator)
+	return n.sendBatches(ctx, len(batches), func(i int) error {
+		msg := WPSMessage{
+			MsgType: "text",
+		}
+		msg.Text.Content = batches[i].text()
+		return n.post(ctx, msg)
+	})
+}
 
+func (n *WPSNotifier) post(ctx context.Context, msg WPSMessage) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
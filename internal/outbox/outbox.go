@@ -0,0 +1,217 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/logger"
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+)
+
+// 未配置时使用的默认值
+const (
+	DefaultMaxAttempts = 10 // 最多发送次数，包括首次推送
+	DefaultExpireHours = 24 // 从首次推送起的重试时长
+)
+
+// 重试间隔从 minBackoff 开始每次翻倍，最长 maxBackoff
+const (
+	minBackoff = time.Minute
+	maxBackoff = time.Hour
+)
+
+// checkInterval 检查重试队列的间隔
+const checkInterval = 30 * time.Second
+
+// MaxAttempts 返回每条消息最多发送的次数
+func MaxAttempts(cfg config.OutboxConfig) int {
+	if cfg.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return cfg.MaxAttempts
+}
+
+// Expire 返回消息从加入重试队列起的有效期
+func Expire(cfg config.OutboxConfig) time.Duration {
+	hours := cfg.ExpireHours
+	if hours <= 0 {
+		hours = DefaultExpireHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Backoff 返回发送 attempts 次失败后到下次重试的间隔：1、2、4、8 分钟……最长 1 小时
+func Backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// entrySeq 消息 ID 的序号，同一次推送中多个渠道失败时 ID 不重复
+var entrySeq atomic.Uint64
+
+// NewEntry 根据首次推送失败的结果创建重试队列中的消息
+func NewEntry(cfg config.OutboxConfig, d model.Delivery, items []*model.NewsItem) *pushdb.OutboxEntry {
+	now := time.Now()
+	return &pushdb.OutboxEntry{
+		ID:          fmt.Sprintf("%d-%d", now.UnixNano(), entrySeq.Add(1)),
+		Channel:     d.Channel,
+		ChannelID:   d.ChannelID,
+		Items:       items,
+		CreatedAt:   now,
+		Attempts:    1,
+		Sent:        d.Batches,
+		NextAttempt: now.Add(Backoff(1)),
+		ExpiresAt:   now.Add(Expire(cfg)),
+		LastError:   d.Error,
+	}
+}
+
+// SendFunc 通过标识为 channelID 的渠道重新发送一条消息，分批发送时跳过前 skip 批
+type SendFunc func(channelID string, items []*model.NewsItem, skip int) model.Delivery
+
+// Retrier 定期重试重试队列中到期的消息
+// 发送成功后从队列中删除；失败时按指数退避安排下次重试，次数用完或过期后移入失败队列
+// 分批发送中途失败时记录已发送的批数，重试时从失败的那一批继续，已发送的批次不会重复
+type Retrier struct {
+	cfg       config.OutboxConfig
+	db        *pushdb.PushDB
+	send      SendFunc
+	isRunning bool
+	stopChan  chan struct{}
+	mu        sync.Mutex
+	retryMu   sync.Mutex // 保证同一时间只有一轮重试
+}
+
+// NewRetrier 创建重试器，send 按当前配置创建通知器发送
+func NewRetrier(cfg config.OutboxConfig, db *pushdb.PushDB, send SendFunc) *Retrier {
+	return &Retrier{
+		cfg:      cfg,
+		db:       db,
+		send:     send,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动重试
+// 即使没有开启 outbox，也会处理队列中已有的消息和在 Web 界面重新发送的消息
+func (r *Retrier) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isRunning {
+		logger.Info("Outbox retrier is already running")
+		return
+	}
+
+	r.isRunning = true
+	r.stopChan = make(chan struct{})
+	stop := r.stopChan
+
+	logger.Infof("Outbox retrier started, checking every %v", checkInterval)
+
+	go func() {
+		// 立即处理一次，重启前未完成的重试不必等待
+		r.RetryDue()
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.Stop()
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				r.RetryDue()
+			}
+		}
+	}()
+}
+
+// Stop 停止重试
+func (r *Retrier) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRunning {
+		return
+	}
+
+	close(r.stopChan)
+	r.isRunning = false
+	logger.Info("Outbox retrier stopped")
+}
+
+// ReloadConfig 更新重试次数和有效期，已在队列中的消息保留原来的过期时间
+func (r *Retrier) ReloadConfig(cfg config.OutboxConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+}
+
+// RetryDue 重试所有到了重试时间的消息
+func (r *Retrier) RetryDue() {
+	r.retryMu.Lock()
+	defer r.retryMu.Unlock()
+
+	r.mu.Lock()
+	maxAttempts := MaxAttempts(r.cfg)
+	r.mu.Unlock()
+
+	entries, err := r.db.GetDueOutbox(time.Now())
+	if err != nil {
+		logger.Errorf("Failed to load outbox: %v", err)
+		return
+	}
+
+	for _, e := range entries {
+		if time.Now().After(e.ExpiresAt) {
+			r.deadLetter(e, "expired")
+			continue
+		}
+
+		d := r.send(e.ChannelID, e.Items, e.Sent)
+		e.Attempts++
+		e.Sent += d.Batches
+		if d.Status == model.DeliverySuccess {
+			logger.Infof("Outbox: delivered %s via %s after %d attempts", e.ID, e.Channel, e.Attempts)
+			if err := r.db.DeleteOutbox(e.ID); err != nil {
+				logger.Errorf("Failed to delete outbox entry %s: %v", e.ID, err)
+			}
+			continue
+		}
+
+		e.LastError = d.Error
+		if e.Attempts >= maxAttempts {
+			r.deadLetter(e, fmt.Sprintf("%d attempts failed", e.Attempts))
+			continue
+		}
+		e.NextAttempt = time.Now().Add(Backoff(e.Attempts))
+		logger.Infof("Outbox: retry %d/%d of %s via %s failed, next at %s: %s",
+			e.Attempts, maxAttempts, e.ID, e.Channel, e.NextAttempt.Format("15:04:05"), d.Error)
+		if err := r.db.UpdateOutbox(e); err != nil {
+			logger.Errorf("Failed to update outbox entry %s: %v", e.ID, err)
+		}
+	}
+}
+
+// deadLetter 把消息移入失败队列
+func (r *Retrier) deadLetter(e *pushdb.OutboxEntry, reason string) {
+	logger.Infof("Outbox: giving up %s via %s (%s): %s", e.ID, e.Channel, reason, e.LastError)
+	if err := r.db.MoveToDeadLetter(e); err != nil {
+		logger.Errorf("Failed to move outbox entry %s to dead letters: %v", e.ID, err)
+	}
+}
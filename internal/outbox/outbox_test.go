@@ -0,0 +1,320 @@
+package outbox
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/pushdb"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	if got := MaxAttempts(config.OutboxConfig{}); got != DefaultMaxAttempts {
+		t.Errorf("MaxAttempts = %d, want %d", got, DefaultMaxAttempts)
+	}
+	if got := Expire(config.OutboxConfig{}); got != DefaultExpireHours*time.Hour {
+		t.Errorf("Expire = %v, want %dh", got, DefaultExpireHours)
+	}
+	if got := Expire(config.OutboxConfig{ExpireHours: 2}); got != 2*time.Hour {
+		t.Errorf("Expire = %v, want 2h", got)
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	cfg := config.OutboxConfig{ExpireHours: 6}
+	d := model.Delivery{Channel: "Webhook(pushplus)", ChannelID: "webhook:pushplus", Status: model.DeliveryFailed, Error: "timeout"}
+	a := NewEntry(cfg, d, testItems())
+	b := NewEntry(cfg, d, testItems())
+
+	if a.ID == b.ID {
+		t.Errorf("entries share ID %s", a.ID)
+	}
+	if a.ChannelID != "webhook:pushplus" || a.Attempts != 1 || a.LastError != "timeout" {
+		t.Errorf("unexpected entry %+v", a)
+	}
+	if got := a.NextAttempt.Sub(a.CreatedAt); got != Backoff(1) {
+		t.Errorf("first retry after %v, want %v", got, Backoff(1))
+	}
+	if got := a.ExpiresAt.Sub(a.CreatedAt); got != 6*time.Hour {
+		t.Errorf("expires after %v, want 6h", got)
+	}
+}
+
+func TestRetryDue(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.OutboxConfig{Enabled: true, MaxAttempts: 3}
+
+	delivered := enqueueDue(t, db, cfg, "feishu")
+	failing := enqueueDue(t, db, cfg, "dingtalk")
+	exhausted := enqueueDue(t, db, cfg, "telegram")
+	exhausted.Attempts = 2
+	if err := db.UpdateOutbox(exhausted); err != nil {
+		t.Fatal(err)
+	}
+	expired := enqueueDue(t, db, cfg, "slack")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := db.UpdateOutbox(expired); err != nil {
+		t.Fatal(err)
+	}
+	later := NewEntry(cfg, model.Delivery{Channel: "Bark", ChannelID: "bark"}, testItems())
+	if err := db.EnqueueOutbox(later); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(map[string]int)
+	r := NewRetrier(cfg, db, func(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+		sent[channelID]++
+		if len(items) != 1 || items[0].Title != "标题" {
+			t.Errorf("%s: items not preserved: %+v", channelID, items)
+		}
+		if channelID == "feishu" {
+			return model.Delivery{ChannelID: channelID, Status: model.DeliverySuccess}
+		}
+		return model.Delivery{ChannelID: channelID, Status: model.DeliveryFailed, Error: "502 Bad Gateway"}
+	})
+	before := time.Now()
+	r.RetryDue()
+
+	if sent["slack"] != 0 {
+		t.Error("expired entry was sent")
+	}
+	if sent["bark"] != 0 {
+		t.Error("entry not yet due was sent")
+	}
+
+	pending := outboxByID(t, db)
+	if _, found := pending[delivered.ID]; found {
+		t.Error("delivered entry still in outbox")
+	}
+	got, found := pending[failing.ID]
+	if !found {
+		t.Fatal("failed entry removed from outbox")
+	}
+	if got.Attempts != 2 || got.LastError != "502 Bad Gateway" {
+		t.Errorf("failed entry not updated: %+v", got)
+	}
+	if next := got.NextAttempt.Sub(before); next < Backoff(2) || next > Backoff(2)+time.Minute {
+		t.Errorf("failed entry rescheduled after %v, want about %v", next, Backoff(2))
+	}
+	if _, found := pending[later.ID]; !found {
+		t.Error("entry not yet due removed from outbox")
+	}
+
+	dead := deadByID(t, db)
+	if len(dead) != 2 {
+		t.Fatalf("got %d dead letters, want 2", len(dead))
+	}
+	if e := dead[exhausted.ID]; e == nil || e.Attempts != 3 || e.FailedAt.IsZero() {
+		t.Errorf("exhausted entry not moved to dead letters: %+v", e)
+	}
+	if e := dead[expired.ID]; e == nil || e.Attempts != 1 {
+		t.Errorf("expired entry not moved to dead letters: %+v", e)
+	}
+
+	// 再次检查时没有到期的消息，不会重复发送
+	r.RetryDue()
+	if sent["dingtalk"] != 1 {
+		t.Errorf("dingtalk sent %d times, want 1", sent["dingtalk"])
+	}
+}
+
+func TestRetryDueResumesBatches(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.OutboxConfig{MaxAttempts: 5}
+	e := NewEntry(cfg, model.Delivery{Channel: "DingTalk", ChannelID: "dingtalk", Status: model.DeliveryFailed, Batches: 2, Error: "batch 3/5: send too fast"}, testItems())
+	e.NextAttempt = time.Now().Add(-time.Second)
+	if err := db.EnqueueOutbox(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Sent != 2 {
+		t.Fatalf("new entry sent = %d, want the 2 batches delivered before the failure", e.Sent)
+	}
+
+	var skips []int
+	r := NewRetrier(cfg, db, func(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+		skips = append(skips, skip)
+		if len(skips) == 1 {
+			return model.Delivery{ChannelID: channelID, Status: model.DeliveryFailed, Batches: 1, Error: "batch 4/5: send too fast"}
+		}
+		return model.Delivery{ChannelID: channelID, Status: model.DeliverySuccess, Batches: 2}
+	})
+	r.RetryDue()
+	got := outboxByID(t, db)[e.ID]
+	if got == nil || got.Sent != 3 {
+		t.Fatalf("entry after partial retry = %+v, want 3 batches sent", got)
+	}
+
+	got.NextAttempt = time.Now().Add(-time.Second)
+	if err := db.UpdateOutbox(got); err != nil {
+		t.Fatal(err)
+	}
+	r.RetryDue()
+	if len(skips) != 2 || skips[0] != 2 || skips[1] != 3 {
+		t.Errorf("retries skipped %v batches, want [2 3]", skips)
+	}
+	if len(outboxByID(t, db)) != 0 {
+		t.Error("delivered entry still in outbox")
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	db := newTestDB(t)
+	r := NewRetrier(config.OutboxConfig{}, db, func(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+		return model.Delivery{ChannelID: channelID, Status: model.DeliverySuccess}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 重启时新建 stopChan，旧的 goroutine 只等待自己的 stopChan
+	for i := 0; i < 3; i++ {
+		r.Start(ctx)
+		time.Sleep(10 * time.Millisecond) // 等 goroutine 进入 select
+		r.Stop()
+	}
+	r.Start(ctx)
+	cancel()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		running := r.isRunning
+		r.mu.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("retrier still running after the context was cancelled")
+		}
+	}
+}
+
+func TestRetryDueDeletedDuringSend(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.OutboxConfig{MaxAttempts: 5}
+	e := enqueueDue(t, db, cfg, "feishu")
+
+	r := NewRetrier(cfg, db, func(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+		// 发送期间在 Web 界面删除了这条消息
+		if err := db.DeleteOutbox(e.ID); err != nil {
+			t.Fatal(err)
+		}
+		return model.Delivery{ChannelID: channelID, Status: model.DeliveryFailed, Error: "timeout"}
+	})
+	r.RetryDue()
+
+	if pending := outboxByID(t, db); len(pending) != 0 {
+		t.Errorf("deleted entry written back: %+v", pending)
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.OutboxConfig{MaxAttempts: 1}
+	e := enqueueDue(t, db, cfg, "dingtalk")
+
+	r := NewRetrier(cfg, db, func(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+		return model.Delivery{ChannelID: channelID, Status: model.DeliveryFailed, Error: "sign not match"}
+	})
+	r.RetryDue()
+	if dead := deadByID(t, db); dead[e.ID] == nil {
+		t.Fatal("entry not moved to dead letters")
+	}
+
+	before := time.Now()
+	if err := db.ReplayDeadLetter(e.ID, 3*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if dead := deadByID(t, db); len(dead) != 0 {
+		t.Errorf("dead letter not removed: %+v", dead)
+	}
+	got := outboxByID(t, db)[e.ID]
+	if got == nil {
+		t.Fatal("replayed entry not in outbox")
+	}
+	if got.Attempts != 0 || !got.FailedAt.IsZero() || got.LastError != "sign not match" {
+		t.Errorf("replayed entry not reset: %+v", got)
+	}
+	if got.NextAttempt.After(time.Now()) {
+		t.Errorf("replayed entry not due: next attempt %v", got.NextAttempt)
+	}
+	if exp := got.ExpiresAt.Sub(before); exp < 3*time.Hour || exp > 3*time.Hour+time.Minute {
+		t.Errorf("replayed entry expires after %v, want 3h", exp)
+	}
+
+	if err := db.ReplayDeadLetter("missing", time.Hour); err == nil {
+		t.Error("replaying a missing dead letter should fail")
+	}
+}
+
+func testItems() []*model.NewsItem {
+	return []*model.NewsItem{{Title: "标题", URL: "https://example.com/1", SourceID: "weibo"}}
+}
+
+func newTestDB(t *testing.T) *pushdb.PushDB {
+	t.Helper()
+	db, err := pushdb.NewPushDB(filepath.Join(t.TempDir(), "push.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// enqueueDue 加入一条已到重试时间的消息
+func enqueueDue(t *testing.T, db *pushdb.PushDB, cfg config.OutboxConfig, channelID string) *pushdb.OutboxEntry {
+	t.Helper()
+	e := NewEntry(cfg, model.Delivery{Channel: channelID, ChannelID: channelID, Status: model.DeliveryFailed, Error: "first"}, testItems())
+	e.NextAttempt = time.Now().Add(-time.Second)
+	if err := db.EnqueueOutbox(e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func outboxByID(t *testing.T, db *pushdb.PushDB) map[string]*pushdb.OutboxEntry {
+	t.Helper()
+	entries, err := db.GetOutbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]*pushdb.OutboxEntry)
+	for _, e := range entries {
+		m[e.ID] = e
+	}
+	return m
+}
+
+func deadByID(t *testing.T, db *pushdb.PushDB) map[string]*pushdb.OutboxEntry {
+	t.Helper()
+	entries, err := db.GetDeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]*pushdb.OutboxEntry)
+	for _, e := range entries {
+		m[e.ID] = e
+	}
+	return m
+}
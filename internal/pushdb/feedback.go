@@ -0,0 +1,178 @@
+package pushdb
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gotoailab/trendhub/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	feedbackBucket = "feedback"
+	metaBucket     = "meta"
+
+	feedbackSecretKey  = "feedback_secret"
+	feedbackAppliedKey = "feedback_applied_at"
+)
+
+// 反馈来源
+const (
+	FeedbackSourceWeb  = "web"  // Web 界面上的 👍/👎 按钮
+	FeedbackSourceLink = "link" // 推送消息中的跟踪链接被点击
+)
+
+// Feedback 读者对一条新闻的反馈
+type Feedback struct {
+	ID           string                `json:"id"`
+	Timestamp    time.Time             `json:"timestamp"`
+	Vote         int                   `json:"vote"`   // 1 有用，-1 没用，推送链接的点击为 0
+	Source       string                `json:"source"` // web, link
+	Title        string                `json:"title"`
+	URL          string                `json:"url,omitempty"`
+	SourceID     string                `json:"source_id"`
+	KeywordGroup string                `json:"keyword_group,omitempty"` // 匹配的关键词组标识
+	Score        *model.ScoreBreakdown `json:"score,omitempty"`         // 反馈时的排序得分明细
+}
+
+// SaveFeedback 保存一条反馈，ID 和时间为空时自动填充
+func (pdb *PushDB) SaveFeedback(fb *Feedback) error {
+	if fb.Timestamp.IsZero() {
+		fb.Timestamp = time.Now()
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(feedbackBucket))
+		if fb.ID == "" {
+			// 以时间戳加序号作为 ID，保证按时间有序且不会冲突
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			fb.ID = fmt.Sprintf("%d-%d", fb.Timestamp.UnixNano(), seq)
+		}
+
+		data, err := json.Marshal(fb)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(fb.ID), data)
+	})
+}
+
+// GetFeedbackSince 获取指定时间之后的反馈，按时间从早到晚排列
+func (pdb *PushDB) GetFeedbackSince(since time.Time) ([]*Feedback, error) {
+	var records []*Feedback
+	err := pdb.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(feedbackBucket))
+		if b == nil {
+			return nil // 只读打开的旧数据库中没有反馈
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var fb Feedback
+			if err := json.Unmarshal(v, &fb); err != nil {
+				return nil
+			}
+			if !fb.Timestamp.Before(since) {
+				records = append(records, &fb)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+// GetRecentFeedback 获取最近的反馈（最新的在前）
+func (pdb *PushDB) GetRecentFeedback(limit int) ([]*Feedback, error) {
+	var records []*Feedback
+	err := pdb.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(feedbackBucket)).Cursor()
+		for k, v := c.Last(); k != nil && len(records) < limit; k, v = c.Prev() {
+			var fb Feedback
+			if err := json.Unmarshal(v, &fb); err != nil {
+				continue
+			}
+			records = append(records, &fb)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// DeleteOldFeedback 删除指定天数之前的反馈
+func (pdb *PushDB) DeleteOldFeedback(days int) (int, error) {
+	deleted := 0
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	err := pdb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(feedbackBucket))
+
+		// 先收集再删除，遍历过程中删除会跳过元素
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var fb Feedback
+			if err := json.Unmarshal(v, &fb); err == nil && fb.Timestamp.Before(cutoff) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(expired)
+		return nil
+	})
+	return deleted, err
+}
+
+// FeedbackSecret 返回签名反馈链接的密钥，首次调用时随机生成并持久化
+func (pdb *PushDB) FeedbackSecret() ([]byte, error) {
+	var secret []byte
+	err := pdb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(metaBucket))
+		if v := b.Get([]byte(feedbackSecretKey)); v != nil {
+			secret = append([]byte(nil), v...)
+			return nil
+		}
+
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		return b.Put([]byte(feedbackSecretKey), secret)
+	})
+	return secret, err
+}
+
+// FeedbackAppliedAt 返回上次应用调整建议时已学习的最新反馈时间，从未应用过时为零值
+func (pdb *PushDB) FeedbackAppliedAt() (time.Time, error) {
+	var applied time.Time
+	err := pdb.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(metaBucket))
+		if b == nil {
+			return nil
+		}
+		if v := b.Get([]byte(feedbackAppliedKey)); v != nil {
+			return applied.UnmarshalText(v)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// SetFeedbackAppliedAt 记录应用调整建议时已学习的最新反馈时间，之后的建议只使用更新的反馈
+func (pdb *PushDB) SetFeedbackAppliedAt(t time.Time) error {
+	data, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(metaBucket)).Put([]byte(feedbackAppliedKey), data)
+	})
+}
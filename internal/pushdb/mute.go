@@ -0,0 +1,59 @@
+package pushdb
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const muteBucket = "mutes"
+
+// Mute 被静音的关键词组，到期之前该组的新闻不推送
+type Mute struct {
+	Group string    `json:"group"` // 关键词组标识
+	Until time.Time `json:"until"`
+}
+
+// MuteGroup 静音关键词组到 until，已静音时更新到期时间
+func (pdb *PushDB) MuteGroup(group string, until time.Time) error {
+	data, err := json.Marshal(&Mute{Group: group, Until: until})
+	if err != nil {
+		return err
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(muteBucket)).Put([]byte(group), data)
+	})
+}
+
+// UnmuteGroup 取消关键词组的静音
+func (pdb *PushDB) UnmuteGroup(group string) error {
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(muteBucket)).Delete([]byte(group))
+	})
+}
+
+// GetMutes 获取未到期的静音，按到期时间排列
+func (pdb *PushDB) GetMutes() ([]*Mute, error) {
+	var mutes []*Mute
+	now := time.Now()
+	err := pdb.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(muteBucket))
+		if b == nil {
+			return nil // 只读打开的旧数据库中没有静音记录
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var m Mute
+			if err := json.Unmarshal(v, &m); err != nil {
+				return nil
+			}
+			if m.Until.After(now) {
+				mutes = append(mutes, &m)
+			}
+			return nil
+		})
+	})
+	sort.Slice(mutes, func(i, j int) bool { return mutes[i].Until.Before(mutes[j].Until) })
+	return mutes, err
+}
@@ -0,0 +1,174 @@
+package pushdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gotoailab/trendhub/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	outboxBucket     = "outbox"
+	deadLetterBucket = "dead_letters"
+)
+
+// OutboxEntry 推送重试队列中的一条消息，对应一次推送中发送失败的一个渠道
+type OutboxEntry struct {
+	ID          string            `json:"id"`
+	Channel     string            `json:"channel"`    // 通知器名称，如 Feishu、Webhook(gotify)，用于显示
+	ChannelID   string            `json:"channel_id"` // 渠道标识，如 feishu、webhook:gotify，重试时据此找到渠道
+	Items       []*model.NewsItem `json:"items"`      // 推送的新闻，重试时按当前的模板重新生成消息
+	CreatedAt   time.Time         `json:"created_at"`
+	Attempts    int               `json:"attempts"` // 已发送的次数，包括首次推送
+	Sent        int               `json:"sent"`     // 分批发送时已成功发送的批数，重试时从下一批继续
+	NextAttempt time.Time         `json:"next_attempt"`
+	ExpiresAt   time.Time         `json:"expires_at"` // 过期后不再重试，移入失败队列
+	LastError   string            `json:"last_error,omitempty"`
+	FailedAt    time.Time         `json:"failed_at"` // 移入失败队列的时间，重试队列中为零值
+}
+
+// EnqueueOutbox 把发送失败的消息加入重试队列
+func (pdb *PushDB) EnqueueOutbox(entry *OutboxEntry) error {
+	return pdb.putEntry(outboxBucket, entry)
+}
+
+// UpdateOutbox 更新重试队列中的消息，消息已被删除时不再写入
+func (pdb *PushDB) UpdateOutbox(entry *OutboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(outboxBucket))
+		if b.Get([]byte(entry.ID)) == nil {
+			return nil
+		}
+		return b.Put([]byte(entry.ID), data)
+	})
+}
+
+// DeleteOutbox 从重试队列中删除消息
+func (pdb *PushDB) DeleteOutbox(id string) error {
+	return pdb.deleteEntry(outboxBucket, id)
+}
+
+// GetOutbox 获取重试队列中的消息，按下次重试时间排列
+func (pdb *PushDB) GetOutbox() ([]*OutboxEntry, error) {
+	entries, err := pdb.listEntries(outboxBucket)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].NextAttempt.Before(entries[j].NextAttempt) })
+	return entries, err
+}
+
+// GetDueOutbox 获取到了重试时间的消息
+func (pdb *PushDB) GetDueOutbox(now time.Time) ([]*OutboxEntry, error) {
+	entries, err := pdb.GetOutbox()
+	if err != nil {
+		return nil, err
+	}
+	var due []*OutboxEntry
+	for _, e := range entries {
+		if e.NextAttempt.After(now) {
+			break
+		}
+		due = append(due, e)
+	}
+	return due, nil
+}
+
+// MoveToDeadLetter 把重试队列中的消息移入失败队列，消息已被删除时不再写入
+func (pdb *PushDB) MoveToDeadLetter(entry *OutboxEntry) error {
+	entry.FailedAt = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		outbox := tx.Bucket([]byte(outboxBucket))
+		if outbox.Get([]byte(entry.ID)) == nil {
+			return nil
+		}
+		if err := outbox.Delete([]byte(entry.ID)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(deadLetterBucket)).Put([]byte(entry.ID), data)
+	})
+}
+
+// GetDeadLetters 获取失败队列中的消息，最近失败的在前
+func (pdb *PushDB) GetDeadLetters() ([]*OutboxEntry, error) {
+	entries, err := pdb.listEntries(deadLetterBucket)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FailedAt.After(entries[j].FailedAt) })
+	return entries, err
+}
+
+// ReplayDeadLetter 把失败队列中的消息移回重试队列并立即重试，重试次数清零，过期时间从现在起重新计算
+func (pdb *PushDB) ReplayDeadLetter(id string, expire time.Duration) error {
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket([]byte(deadLetterBucket))
+		data := dead.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("dead letter not found")
+		}
+		var entry OutboxEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		entry.Attempts = 0
+		entry.NextAttempt = now
+		entry.ExpiresAt = now.Add(expire)
+		entry.FailedAt = time.Time{}
+		data, err := json.Marshal(&entry)
+		if err != nil {
+			return err
+		}
+		if err := dead.Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(outboxBucket)).Put([]byte(id), data)
+	})
+}
+
+// DeleteDeadLetter 从失败队列中删除消息
+func (pdb *PushDB) DeleteDeadLetter(id string) error {
+	return pdb.deleteEntry(deadLetterBucket, id)
+}
+
+func (pdb *PushDB) putEntry(bucket string, entry *OutboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucket)).Put([]byte(entry.ID), data)
+	})
+}
+
+func (pdb *PushDB) deleteEntry(bucket, id string) error {
+	return pdb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucket)).Delete([]byte(id))
+	})
+}
+
+func (pdb *PushDB) listEntries(bucket string) ([]*OutboxEntry, error) {
+	var entries []*OutboxEntry
+	err := pdb.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil // 只读打开的旧数据库中没有重试队列
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var e OutboxEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return nil
+			}
+			entries = append(entries, &e)
+			return nil
+		})
+	})
+	return entries, err
+}
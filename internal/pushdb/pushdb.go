@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gotoailab/trendhub/internal/model"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -14,15 +15,16 @@ const (
 
 // PushRecord 推送记录
 type PushRecord struct {
-	ID          string    `json:"id"`
-	Timestamp   time.Time `json:"timestamp"`
-	Status      string    `json:"status"` // success, failed, partial
-	ItemCount   int       `json:"item_count"`
-	Notifiers   []string  `json:"notifiers"`
-	SuccessNum  int       `json:"success_num"`
-	FailedNum   int       `json:"failed_num"`
-	ErrorMsg    string    `json:"error_msg,omitempty"`
-	Duration    int64     `json:"duration"` // 毫秒
+	ID         string           `json:"id"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Status     string           `json:"status"` // success, failed, partial
+	ItemCount  int              `json:"item_count"`
+	Notifiers  []string         `json:"notifiers"`
+	SuccessNum int              `json:"success_num"`
+	FailedNum  int              `json:"failed_num"`
+	ErrorMsg   string           `json:"error_msg,omitempty"`
+	Duration   int64            `json:"duration"`             // 毫秒
+	Deliveries []model.Delivery `json:"deliveries,omitempty"` // 各推送渠道的发送结果
 }
 
 // PushDB 推送记录数据库
@@ -39,8 +41,12 @@ func NewPushDB(dbPath string) (*PushDB, error) {
 
 	// 创建 bucket
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(pushBucket))
-		return err
+		for _, name := range []string{pushBucket, feedbackBucket, metaBucket, muteBucket, outboxBucket, deadLetterBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		db.Close()
@@ -50,6 +56,15 @@ func NewPushDB(dbPath string) (*PushDB, error) {
 	return &PushDB{db: db}, nil
 }
 
+// OpenReadOnly 以只读方式打开推送记录数据库，用于离线读取反馈数据
+func OpenReadOnly(dbPath string) (*PushDB, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &PushDB{db: db}, nil
+}
+
 // Close 关闭数据库
 func (pdb *PushDB) Close() error {
 	return pdb.db.Close()
@@ -59,12 +74,12 @@ func (pdb *PushDB) Close() error {
 func (pdb *PushDB) SaveRecord(record *PushRecord) error {
 	return pdb.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
-		
+
 		data, err := json.Marshal(record)
 		if err != nil {
 			return err
 		}
-		
+
 		return b.Put([]byte(record.ID), data)
 	})
 }
@@ -72,7 +87,7 @@ func (pdb *PushDB) SaveRecord(record *PushRecord) error {
 // GetRecord 获取单条记录
 func (pdb *PushDB) GetRecord(id string) (*PushRecord, error) {
 	var record PushRecord
-	
+
 	err := pdb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		data := b.Get([]byte(id))
@@ -81,7 +96,7 @@ func (pdb *PushDB) GetRecord(id string) (*PushRecord, error) {
 		}
 		return json.Unmarshal(data, &record)
 	})
-	
+
 	if err != nil {
 		return nil, err
 	}
@@ -91,25 +106,25 @@ func (pdb *PushDB) GetRecord(id string) (*PushRecord, error) {
 // GetRecords 获取推送记录列表（分页）
 func (pdb *PushDB) GetRecords(limit int, offset int) ([]*PushRecord, error) {
 	var records []*PushRecord
-	
+
 	err := pdb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		c := b.Cursor()
-		
+
 		// 反向遍历（最新的在前）
 		count := 0
 		skipped := 0
-		
+
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
 			if skipped < offset {
 				skipped++
 				continue
 			}
-			
+
 			if count >= limit {
 				break
 			}
-			
+
 			var record PushRecord
 			if err := json.Unmarshal(v, &record); err != nil {
 				continue
@@ -117,23 +132,23 @@ func (pdb *PushDB) GetRecords(limit int, offset int) ([]*PushRecord, error) {
 			records = append(records, &record)
 			count++
 		}
-		
+
 		return nil
 	})
-	
+
 	return records, err
 }
 
 // GetRecordCount 获取记录总数
 func (pdb *PushDB) GetRecordCount() (int, error) {
 	count := 0
-	
+
 	err := pdb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		count = b.Stats().KeyN
 		return nil
 	})
-	
+
 	return count, err
 }
 
@@ -141,17 +156,17 @@ func (pdb *PushDB) GetRecordCount() (int, error) {
 func (pdb *PushDB) DeleteOldRecords(days int) (int, error) {
 	deleted := 0
 	cutoff := time.Now().AddDate(0, 0, -days)
-	
+
 	err := pdb.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		c := b.Cursor()
-		
+
 		for k, v := c.First(); k != nil; k, v = c.Next() {
 			var record PushRecord
 			if err := json.Unmarshal(v, &record); err != nil {
 				continue
 			}
-			
+
 			if record.Timestamp.Before(cutoff) {
 				if err := b.Delete(k); err != nil {
 					return err
@@ -159,35 +174,37 @@ func (pdb *PushDB) DeleteOldRecords(days int) (int, error) {
 				deleted++
 			}
 		}
-		
+
 		return nil
 	})
-	
+
 	return deleted, err
 }
 
 // GetLastPushTime 获取最后一次推送时间（用于 once_per_day 检查）
+// 任务在发送前就出错（状态为 failed 且没有任何渠道的发送结果）时没有推送，不计入
 func (pdb *PushDB) GetLastPushTime() (time.Time, error) {
 	var lastTime time.Time
-	
+
 	err := pdb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		c := b.Cursor()
-		
-		k, v := c.Last()
-		if k == nil {
+
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var record PushRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if record.Status == "failed" && len(record.Deliveries) == 0 {
+				continue
+			}
+
+			lastTime = record.Timestamp
 			return nil
 		}
-		
-		var record PushRecord
-		if err := json.Unmarshal(v, &record); err != nil {
-			return err
-		}
-		
-		lastTime = record.Timestamp
 		return nil
 	})
-	
+
 	return lastTime, err
 }
 
@@ -196,17 +213,17 @@ func (pdb *PushDB) ClearTodayRecords() (int, error) {
 	deleted := 0
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	
+
 	err := pdb.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(pushBucket))
 		c := b.Cursor()
-		
+
 		for k, v := c.First(); k != nil; k, v = c.Next() {
 			var record PushRecord
 			if err := json.Unmarshal(v, &record); err != nil {
 				continue
 			}
-			
+
 			// 如果记录是今天的，删除
 			if record.Timestamp.After(today) || record.Timestamp.Equal(today) {
 				if err := c.Delete(); err != nil {
@@ -217,6 +234,6 @@ func (pdb *PushDB) ClearTodayRecords() (int, error) {
 		}
 		return nil
 	})
-	
+
 	return deleted, err
 }
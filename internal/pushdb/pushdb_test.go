@@ -0,0 +1,44 @@
+package pushdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+func TestGetLastPushTime(t *testing.T) {
+	db, err := NewPushDB(filepath.Join(t.TempDir(), "push.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if last, err := db.GetLastPushTime(); err != nil || !last.IsZero() {
+		t.Fatalf("empty database: %v, %v", last, err)
+	}
+
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.Local)
+	records := []*PushRecord{
+		{Status: "success", Deliveries: []model.Delivery{{Channel: "Feishu", Status: model.DeliverySuccess}}},
+		{Status: "failed", Deliveries: []model.Delivery{{Channel: "Feishu", Status: model.DeliveryFailed}}}, // 发送失败，已加入重试队列
+		{Status: "failed", ErrorMsg: "failed to crawl"},                                                     // 发送前出错
+	}
+	for i, r := range records {
+		r.Timestamp = start.Add(time.Duration(i) * time.Hour)
+		r.ID = fmt.Sprintf("%d", r.Timestamp.UnixNano())
+		if err := db.SaveRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, err := db.GetLastPushTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.Equal(records[1].Timestamp) {
+		t.Errorf("last push time = %v, want %v (the run that failed before sending is skipped)", last, records[1].Timestamp)
+	}
+}
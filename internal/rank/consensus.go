@@ -0,0 +1,147 @@
+package rank
+
+import (
+	"sort"
+
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// DefaultConsensusSimilarity 判断两条标题为同一事件的默认相似度阈值
+const DefaultConsensusSimilarity = 0.6
+
+// story 跨平台归并后的同一事件
+type story struct {
+	platforms []string // 上榜的平台ID，已排序去重
+	weight    float64  // 各平台权重之和
+}
+
+// clusterStories 按标题把不同平台上的同一事件归为一组，返回每条新闻所属的组号
+// 标题去掉空白和标点后完全相同，或字符二元组的 Jaccard 相似度不低于 threshold 时视为同一事件
+func clusterStories(items []*model.NewsItem, threshold float64) []int {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultConsensusSimilarity
+	}
+
+	// 先按规范化标题合并完全相同的标题
+	keyIndex := make(map[string]int)
+	var keys []string
+	itemKey := make([]int, len(items))
+	for i, item := range items {
+		key := normalizeTitle(item.Title)
+		k, ok := keyIndex[key]
+		if !ok {
+			k = len(keys)
+			keyIndex[key] = k
+			keys = append(keys, key)
+		}
+		itemKey[i] = k
+	}
+
+	// 再按二元组相似度合并相近的标题
+	parent := make([]int, len(keys))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+
+	grams := make([]map[string]struct{}, len(keys))
+	for i, key := range keys {
+		grams[i] = bigrams(key)
+	}
+	for i := range keys {
+		if len(grams[i]) == 0 {
+			continue
+		}
+		for j := i + 1; j < len(keys); j++ {
+			if len(grams[j]) == 0 || !sizeCompatible(len(grams[i]), len(grams[j]), threshold) {
+				continue
+			}
+			if jaccard(grams[i], grams[j]) >= threshold {
+				if a, b := find(i), find(j); a != b {
+					parent[b] = a
+				}
+			}
+		}
+	}
+
+	clusters := make([]int, len(items))
+	for i := range items {
+		clusters[i] = find(itemKey[i])
+	}
+	return clusters
+}
+
+// buildStories 汇总每个事件上榜的平台及其权重之和
+func buildStories(items []*model.NewsItem, clusters []int, platforms map[string]float64) map[int]*story {
+	seen := make(map[int]map[string]bool)
+	stories := make(map[int]*story)
+	for i, item := range items {
+		c := clusters[i]
+		if seen[c] == nil {
+			seen[c] = make(map[string]bool)
+			stories[c] = &story{}
+		}
+		if seen[c][item.SourceID] {
+			continue
+		}
+		seen[c][item.SourceID] = true
+
+		s := stories[c]
+		s.platforms = append(s.platforms, item.SourceID)
+		weight, ok := platforms[item.SourceID]
+		if !ok {
+			weight = 1.0
+		}
+		s.weight += weight
+	}
+	for _, s := range stories {
+		sort.Strings(s.platforms)
+	}
+	return stories
+}
+
+// bigrams 返回字符串的字符二元组集合，单个字符的字符串以自身作为唯一元素
+func bigrams(s string) map[string]struct{} {
+	runes := []rune(s)
+	set := make(map[string]struct{})
+	if len(runes) == 1 {
+		set[s] = struct{}{}
+		return set
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = struct{}{}
+	}
+	return set
+}
+
+// sizeCompatible 集合大小相差过大时 Jaccard 相似度不可能达到阈值，跳过比较
+func sizeCompatible(a, b int, threshold float64) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return float64(a) >= threshold*float64(b)
+}
+
+// jaccard 计算两个集合的 Jaccard 相似度
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	inter := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
@@ -0,0 +1,76 @@
+package rank
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+func TestClusterStories(t *testing.T) {
+	items := []*model.NewsItem{
+		{Title: "华为发布会！", SourceID: "weibo"},
+		{Title: "华为 发布会", SourceID: "zhihu"}, // 去掉空白和标点后相同
+		{Title: "华为发布了", SourceID: "baidu"},  // 二元组 Jaccard 相似度 3/5 = 0.6
+		{Title: "今天天气晴朗", SourceID: "toutiao"},
+	}
+
+	tests := []struct {
+		threshold float64
+		same      []bool // 与第一条是否为同一事件
+	}{
+		{0, []bool{true, true, true, false}}, // 默认阈值 0.6，恰好达到阈值时归并
+		{0.6, []bool{true, true, true, false}},
+		{0.7, []bool{true, true, false, false}},
+		{1, []bool{true, true, false, false}}, // 只合并规范化后相同的标题
+	}
+	for _, tt := range tests {
+		clusters := clusterStories(items, tt.threshold)
+		for i, want := range tt.same {
+			if got := clusters[i] == clusters[0]; got != want {
+				t.Errorf("threshold %.1f: %s clustered with %s = %v, want %v",
+					tt.threshold, items[i].Title, items[0].Title, got, want)
+			}
+		}
+	}
+}
+
+func TestConsensusScore(t *testing.T) {
+	platforms := []model.Platform{
+		{ID: "weibo", Weight: 1},
+		{ID: "zhihu", Weight: 0.5},
+		{ID: "toutiao", Weight: 1},
+	}
+	r := NewWeightedRanker(config.WeightConfig{ConsensusWeight: 1}, platforms)
+	weibo := &model.NewsItem{Title: "华为发布会", SourceID: "weibo", Ranks: []int{1}}
+	zhihu := &model.NewsItem{Title: "华为发布会", SourceID: "zhihu", Ranks: []int{1}}
+	toutiao := &model.NewsItem{Title: "今天天气晴朗", SourceID: "toutiao", Ranks: []int{1}}
+	r.Rank(map[string][]*model.NewsItem{
+		"weibo":   {weibo},
+		"zhihu":   {zhihu},
+		"toutiao": {toutiao},
+	})
+
+	// 共识分 = 100 × W / (W + 1)，W 为同一事件所在其他平台的权重之和
+	tests := []struct {
+		item *model.NewsItem
+		want float64
+	}{
+		{weibo, 100 * 0.5 / 1.5},
+		{zhihu, 100 * 1 / 2.0},
+		{toutiao, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.item.Score.Consensus; math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s consensus = %.2f, want %.2f", tt.item.SourceID, got, tt.want)
+		}
+	}
+	if want := []string{"weibo", "zhihu"}; !reflect.DeepEqual(weibo.Score.ConsensusPlatforms, want) {
+		t.Errorf("consensus platforms = %v, want %v", weibo.Score.ConsensusPlatforms, want)
+	}
+	if toutiao.Score.ConsensusPlatforms != nil {
+		t.Errorf("single-platform story listed platforms %v", toutiao.Score.ConsensusPlatforms)
+	}
+}
@@ -0,0 +1,90 @@
+package rank
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// 排名分曲线：把平台榜单上的排名换算为 0-100 的排名分
+const (
+	RankCurveReciprocal = "reciprocal" // 100 / 排名，默认
+	RankCurveLinear     = "linear"     // 按在榜单中的位置线性递减，末位接近0
+	RankCurveLog        = "log"        // 按排名的对数递减，介于 reciprocal 和 linear 之间
+)
+
+// checkRankCurve 校验排名分曲线名称，空值表示默认的 reciprocal
+func checkRankCurve(curve string) error {
+	switch strings.ToLower(strings.TrimSpace(curve)) {
+	case "", RankCurveReciprocal, RankCurveLinear, RankCurveLog:
+		return nil
+	}
+	return fmt.Errorf("unknown rank curve %q (available: %s, %s, %s)", curve, RankCurveReciprocal, RankCurveLinear, RankCurveLog)
+}
+
+// rankScale 一批新闻的排名换算规则
+// 各平台的榜单长度取抓取时记录的榜单条数，旧数据没有记录时取这批新闻中该平台出现的最大排名
+type rankScale struct {
+	curve     string
+	normalize bool
+	boards    map[string]int // 平台ID -> 榜单长度
+	reference int            // 归一化时的参考榜单长度：最长的榜单
+}
+
+// newRankScale 统计各平台的榜单长度
+func newRankScale(cfg config.WeightConfig, items []*model.NewsItem) *rankScale {
+	s := &rankScale{
+		curve:     strings.ToLower(strings.TrimSpace(cfg.RankCurve)),
+		normalize: cfg.RankNormalize,
+		boards:    make(map[string]int),
+	}
+	for _, item := range items {
+		size := item.BoardSize
+		if rank := firstRank(item); rank > size {
+			size = rank
+		}
+		if size > s.boards[item.SourceID] {
+			s.boards[item.SourceID] = size
+		}
+	}
+
+	for _, size := range s.boards {
+		if size > s.reference {
+			s.reference = size
+		}
+	}
+	return s
+}
+
+// rank 返回新闻的排名和所在榜单的长度
+// 开启归一化时按百分位换算：第 r 名在长度为 N 的榜单上位于前 (r-0.5)/N，换算为参考榜单上同一百分位的排名
+// 最长榜单的排名不变，较短榜单的第1名不再与最长榜单的第1名相同，如 10 条榜单的第1名相当于 50 条榜单的第3名
+func (s *rankScale) rank(item *model.NewsItem) (float64, int) {
+	rank := firstRank(item)
+	board := s.boards[item.SourceID]
+	if board < rank {
+		board = rank
+	}
+	if !s.normalize || s.reference <= 0 {
+		return float64(rank), board
+	}
+	return (float64(rank)-0.5)*float64(s.reference)/float64(board) + 0.5, s.reference
+}
+
+// score 按配置的曲线计算排名分 (0-100)
+func (s *rankScale) score(item *model.NewsItem) float64 {
+	rank, board := s.rank(item)
+	switch s.curve {
+	case RankCurveLinear:
+		// 第1名100，之后每名递减 100/榜单长度
+		return 100.0 * (float64(board) - rank + 1) / float64(board)
+	case RankCurveLog:
+		// 第1名100，榜单长度+1 处为0
+		return 100.0 * (1 - math.Log(rank)/math.Log(float64(board)+1))
+	default:
+		return 100.0 / rank
+	}
+}
@@ -0,0 +1,76 @@
+package rank
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+func boardItem(source string, rank, board int) *model.NewsItem {
+	return &model.NewsItem{SourceID: source, Ranks: []int{rank}, BoardSize: board}
+}
+
+func TestRankScaleNormalize(t *testing.T) {
+	short1 := boardItem("short", 1, 10)
+	short5 := boardItem("short", 5, 10)
+	shortLast := boardItem("short", 10, 10)
+	long1 := boardItem("long", 1, 50)
+	long23 := boardItem("long", 23, 50)
+	longLast := boardItem("long", 50, 50)
+	mid := boardItem("mid", 1, 30)
+	items := []*model.NewsItem{short1, short5, shortLast, long1, long23, longLast, mid}
+
+	for _, curve := range []string{RankCurveReciprocal, RankCurveLinear, RankCurveLog} {
+		s := newRankScale(config.WeightConfig{RankCurve: curve, RankNormalize: true}, items)
+
+		// 最长榜单的第1名满分，较短榜单的第1名按百分位低于它
+		if got := s.score(long1); math.Abs(got-100) > 1e-9 {
+			t.Errorf("%s: long board leader = %.2f, want 100", curve, got)
+		}
+		if s.score(short1) >= s.score(mid) || s.score(mid) >= s.score(long1) {
+			t.Errorf("%s: leaders not ordered by board length: short %.2f, mid %.2f, long %.2f",
+				curve, s.score(short1), s.score(mid), s.score(long1))
+		}
+		// 同一百分位（前 45%）的排名得分相同
+		if math.Abs(s.score(short5)-s.score(long23)) > 1e-9 {
+			t.Errorf("%s: same percentile scored differently: %.2f vs %.2f", curve, s.score(short5), s.score(long23))
+		}
+		// 末位得分很低，且不超过任何更靠前的排名
+		if s.score(shortLast) >= s.score(short5) || s.score(longLast) >= s.score(long23) {
+			t.Errorf("%s: last place scored above a higher rank", curve)
+		}
+		for _, item := range items {
+			if got := s.score(item); got < 0 || got > 100 {
+				t.Errorf("%s: score %.2f out of range for rank %d/%d", curve, got, item.Ranks[0], item.BoardSize)
+			}
+		}
+	}
+}
+
+func TestRankScaleWithoutNormalize(t *testing.T) {
+	items := []*model.NewsItem{boardItem("short", 1, 10), boardItem("long", 1, 50), boardItem("long", 4, 50)}
+	s := newRankScale(config.WeightConfig{}, items)
+
+	if s.score(items[0]) != 100 || s.score(items[1]) != 100 {
+		t.Errorf("leaders = %.2f, %.2f, want 100 without normalization", s.score(items[0]), s.score(items[1]))
+	}
+	if got := s.score(items[2]); got != 25 {
+		t.Errorf("rank 4 = %.2f, want 25", got)
+	}
+}
+
+func TestRankScaleBoardFallback(t *testing.T) {
+	// 旧数据没有记录榜单长度时，取该平台出现的最大排名
+	items := []*model.NewsItem{boardItem("old", 1, 0), boardItem("old", 20, 0), boardItem("new", 1, 40)}
+	s := newRankScale(config.WeightConfig{RankNormalize: true}, items)
+
+	if s.boards["old"] != 20 || s.reference != 40 {
+		t.Fatalf("boards = %v, reference = %d", s.boards, s.reference)
+	}
+	rank, board := s.rank(items[0])
+	if math.Abs(rank-1.5) > 1e-9 || board != 40 {
+		t.Errorf("rank 1/20 normalized to %.2f on %d, want 1.5 on 40", rank, board)
+	}
+}
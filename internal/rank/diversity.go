@@ -0,0 +1,127 @@
+package rank
+
+import (
+	"math"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// DefaultDiversityWindow 多样性重排的默认范围（前 N 条）
+const DefaultDiversityWindow = 20
+
+// DiversityRanker 在其他排序策略之后执行的多样性重排
+// 按配额轮转：在前 Window 条内依次选取排名最高、且所属平台和关键词组都未超出配额的新闻；
+// 没有满足配额的新闻时放宽限制，按原顺序补齐，窗口之后的新闻保持原顺序
+type DiversityRanker struct {
+	inner            Ranker
+	window           int
+	maxPlatformShare float64
+	maxGroupShare    float64
+}
+
+// NewDiversityRanker 用多样性重排包装排序策略
+func NewDiversityRanker(inner Ranker, cfg config.DiversityConfig) *DiversityRanker {
+	window := cfg.Window
+	if window <= 0 {
+		window = DefaultDiversityWindow
+	}
+	return &DiversityRanker{
+		inner:            inner,
+		window:           window,
+		maxPlatformShare: cfg.MaxPlatformShare,
+		maxGroupShare:    cfg.MaxGroupShare,
+	}
+}
+
+func (r *DiversityRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	ranked := r.inner.Rank(data)
+	if len(ranked) == 0 {
+		return ranked
+	}
+
+	window := r.window
+	if window > len(ranked) {
+		window = len(ranked)
+	}
+	platformCap := shareCap(r.maxPlatformShare, window)
+	groupCap := shareCap(r.maxGroupShare, window)
+
+	platformCount := make(map[string]int)
+	groupCount := make(map[int]int)
+	used := make([]bool, len(ranked))
+	result := make([]*model.NewsItem, 0, len(ranked))
+
+	for len(result) < window {
+		pick := -1
+		for i, item := range ranked {
+			if used[i] {
+				continue
+			}
+			if platformCount[item.SourceID] >= platformCap {
+				continue
+			}
+			if group, ok := keywordGroupOf(item); ok && groupCount[group] >= groupCap {
+				continue
+			}
+			pick = i
+			break
+		}
+		// 所有剩余新闻都超出配额时放宽限制，按原顺序补齐
+		if pick < 0 {
+			for i := range ranked {
+				if !used[i] {
+					pick = i
+					break
+				}
+			}
+		}
+
+		item := ranked[pick]
+		used[pick] = true
+		platformCount[item.SourceID]++
+		if group, ok := keywordGroupOf(item); ok {
+			groupCount[group]++
+		}
+		result = append(result, item)
+	}
+
+	for i, item := range ranked {
+		if !used[i] {
+			result = append(result, item)
+		}
+	}
+
+	for i, item := range result {
+		if item.Score == nil {
+			continue
+		}
+		if item.Score.Position != i+1 {
+			item.Score.OriginalPosition = item.Score.Position
+			item.Score.Position = i + 1
+		} else {
+			item.Score.OriginalPosition = 0
+		}
+	}
+	return result
+}
+
+// shareCap 将占比换算为窗口内的条数上限，未配置时不限制
+func shareCap(share float64, window int) int {
+	if share <= 0 || share >= 1 {
+		return math.MaxInt
+	}
+	n := int(math.Ceil(share * float64(window)))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// keywordGroupOf 返回新闻匹配的关键词组，未匹配任何组（如未配置关键词）时返回 false
+func keywordGroupOf(item *model.NewsItem) (int, bool) {
+	if item.MatchScore <= 0 || item.KeywordGroup < 0 {
+		return 0, false
+	}
+	return item.KeywordGroup, true
+}
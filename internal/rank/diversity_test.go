@@ -0,0 +1,116 @@
+package rank
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// fixedRanker 按给定顺序返回新闻，用于单独测试多样性重排
+type fixedRanker []*model.NewsItem
+
+func (r fixedRanker) Rank(map[string][]*model.NewsItem) []*model.NewsItem {
+	for i, item := range r {
+		item.Score = &model.ScoreBreakdown{Position: i + 1}
+	}
+	return append([]*model.NewsItem(nil), r...)
+}
+
+// diversityItem 标题即标识；group 为 -1 时表示未匹配关键词
+func diversityItem(title, source string, group int) *model.NewsItem {
+	item := &model.NewsItem{Title: title, SourceID: source, KeywordGroup: group}
+	if group >= 0 {
+		item.MatchScore = 10
+	}
+	return item
+}
+
+func diversityOrder(items []*model.NewsItem) string {
+	var titles []string
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	return strings.Join(titles, " ")
+}
+
+func TestDiversityQuotas(t *testing.T) {
+	inner := fixedRanker{
+		diversityItem("w1", "weibo", 0),
+		diversityItem("w2", "weibo", 0),
+		diversityItem("w3", "weibo", 0),
+		diversityItem("w4", "weibo", 1),
+		diversityItem("z1", "zhihu", 0),
+		diversityItem("b1", "baidu", 1),
+		diversityItem("b2", "baidu", -1),
+		diversityItem("z2", "zhihu", 1),
+	}
+	r := NewDiversityRanker(inner, config.DiversityConfig{Window: 4, MaxPlatformShare: 0.5, MaxGroupShare: 0.5})
+	ranked := r.Rank(nil)
+
+	// 窗口 4 条内每个平台、每个关键词组最多 2 条：w3 超出微博配额，z1 超出组 0 配额，未匹配关键词的 b2 不受组配额限制
+	if got, want := diversityOrder(ranked), "w1 w2 b1 b2 w3 w4 z1 z2"; got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+	platforms := make(map[string]int)
+	groups := make(map[int]int)
+	for _, item := range ranked[:4] {
+		platforms[item.SourceID]++
+		if group, ok := keywordGroupOf(item); ok {
+			groups[group]++
+		}
+	}
+	for id, n := range platforms {
+		if n > 2 {
+			t.Errorf("platform %s has %d items in the window, want at most 2", id, n)
+		}
+	}
+	for group, n := range groups {
+		if n > 2 {
+			t.Errorf("group %d has %d items in the window, want at most 2", group, n)
+		}
+	}
+	if s := ranked[2].Score; s.Position != 3 || s.OriginalPosition != 6 {
+		t.Errorf("b1 score = %+v, want moved from 6 to 3", s)
+	}
+	if s := ranked[0].Score; s.Position != 1 || s.OriginalPosition != 0 {
+		t.Errorf("w1 score = %+v, want unchanged", s)
+	}
+}
+
+func TestDiversityRotationFallback(t *testing.T) {
+	inner := fixedRanker{
+		diversityItem("w1", "weibo", -1),
+		diversityItem("w2", "weibo", -1),
+		diversityItem("w3", "weibo", -1),
+		diversityItem("z1", "zhihu", -1),
+		diversityItem("w4", "weibo", -1),
+	}
+	r := NewDiversityRanker(inner, config.DiversityConfig{Window: 4, MaxPlatformShare: 0.5})
+	ranked := r.Rank(nil)
+
+	// 知乎只有一条，用完后所有剩余新闻都超出微博配额，放宽限制按原顺序补齐窗口
+	if got, want := diversityOrder(ranked), "w1 w2 z1 w3 w4"; got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+	wantOriginal := []int{0, 0, 4, 3, 0}
+	for i, item := range ranked {
+		if item.Score.Position != i+1 || item.Score.OriginalPosition != wantOriginal[i] {
+			t.Errorf("%s score = %+v, want position %d from %d", item.Title, item.Score, i+1, wantOriginal[i])
+		}
+	}
+}
+
+func TestDiversityDisabled(t *testing.T) {
+	inner := fixedRanker{
+		diversityItem("w1", "weibo", 0),
+		diversityItem("w2", "weibo", 0),
+		diversityItem("z1", "zhihu", 1),
+	}
+	// 未配置占比时不限制，保持原顺序
+	ranked := NewDiversityRanker(inner, config.DiversityConfig{}).Rank(nil)
+	if got, want := diversityOrder(ranked), "w1 w2 z1"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package rank
+
+import "github.com/gotoailab/trendhub/internal/model"
+
+// Boosts 由阅读反馈学习得到的调整系数，1 表示不调整
+type Boosts struct {
+	Platforms map[string]float64 // 平台ID -> 系数
+	Groups    map[string]float64 // 关键词组标识 -> 系数
+}
+
+// factor 返回新闻所在平台和关键词组的系数之积
+func (b *Boosts) factor(item *model.NewsItem) float64 {
+	if b == nil {
+		return 1
+	}
+	f := 1.0
+	if v, ok := b.Platforms[item.SourceID]; ok && v > 0 {
+		f *= v
+	}
+	if item.KeywordGroupKey != "" {
+		if v, ok := b.Groups[item.KeywordGroupKey]; ok && v > 0 {
+			f *= v
+		}
+	}
+	return f
+}
+
+// FeedbackRanker 在加权分的基础上乘以阅读反馈学习得到的平台和关键词组系数
+// 被标记为“有用”较多的平台和关键词组排名上升，“没用”较多的下降
+type FeedbackRanker struct {
+	base   *WeightedRanker
+	boosts *Boosts
+}
+
+// NewFeedbackRanker 创建反馈学习排序，没有反馈数据时与 weighted 相同
+func NewFeedbackRanker(opts Options) *FeedbackRanker {
+	return &FeedbackRanker{
+		base:   newBase(opts),
+		boosts: opts.Boosts,
+	}
+}
+
+func (r *FeedbackRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	scores := r.base.scoreAll(allItems, r.base.now())
+
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		score := scores[i]
+		score.Strategy = StrategyFeedback
+		score.Feedback = r.boosts.factor(item)
+		score.Total = score.Weighted * score.Feedback
+		item.Score = score
+		keys[i] = []float64{score.Total, score.Weighted}
+	}
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
+	return allItems
+}
@@ -0,0 +1,216 @@
+package rank
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// 内置排序策略名称
+const (
+	StrategyWeighted             = "weighted"
+	StrategyRecencyDecay         = "recency-decay"
+	StrategyVelocityFirst        = "velocity-first"
+	StrategyKeywordPriorityFirst = "keyword-priority-first"
+	StrategyReciprocalRankFusion = "reciprocal-rank-fusion"
+	StrategyRRF                  = "rrf" // reciprocal-rank-fusion 的简写
+	StrategyFeedback             = "feedback"
+)
+
+// 配置项为0时使用的默认值
+const (
+	DefaultKeywordWeight        = 0.3  // 关键词匹配权重
+	DefaultFreshnessWeight      = 0.1  // 时效性权重
+	DefaultPlatformWeightEffect = 1.0  // 平台权重影响系数
+	DefaultRRFK                 = 60.0 // reciprocal-rank-fusion 平滑常数
+	DefaultDecayHalfLife        = 6.0  // recency-decay 半衰期（小时）
+	DefaultFreshnessHalfLife    = 2.0  // 时效性分半衰期（小时）
+	DefaultPriority             = 5    // 关键词组默认优先级
+)
+
+type Ranker interface {
+	Rank(items map[string][]*model.NewsItem) []*model.NewsItem
+}
+
+// Options 创建排序策略所需的参数
+type Options struct {
+	Weight    config.WeightConfig
+	Platforms []model.Platform
+	Groups    []config.KeywordGroup // 关键词组，用于读取匹配组的优先级
+	Now       func() time.Time      // 当前时间，为空时使用 time.Now；回放历史数据时可指定
+	Boosts    *Boosts               // 由阅读反馈学习得到的调整系数，feedback 策略使用
+}
+
+// Factory 排序策略构造函数
+type Factory func(opts Options) (Ranker, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册排序策略，同名策略会被覆盖
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Strategies 返回已注册的策略名称
+func Strategies() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New 按 opts.Weight.Strategy 创建排序策略，未配置时使用 weighted；开启多样性重排时包装为 DiversityRanker
+func New(opts Options) (Ranker, error) {
+	name := strings.ToLower(strings.TrimSpace(opts.Weight.Strategy))
+	if name == "" {
+		name = StrategyWeighted
+	}
+
+	if err := checkRankCurve(opts.Weight.RankCurve); err != nil {
+		return nil, err
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown rank strategy %q (available: %s)", name, strings.Join(Strategies(), ", "))
+	}
+	r, err := factory(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多样性重排作用于任意策略的结果
+	if opts.Weight.Diversity.Enabled {
+		r = NewDiversityRanker(r, opts.Weight.Diversity)
+	}
+	return r, nil
+}
+
+func init() {
+	Register(StrategyWeighted, func(opts Options) (Ranker, error) {
+		return newBase(opts), nil
+	})
+	Register(StrategyRecencyDecay, func(opts Options) (Ranker, error) {
+		return NewRecencyDecayRanker(opts), nil
+	})
+	Register(StrategyVelocityFirst, func(opts Options) (Ranker, error) {
+		return NewVelocityRanker(opts), nil
+	})
+	Register(StrategyKeywordPriorityFirst, func(opts Options) (Ranker, error) {
+		return NewKeywordPriorityRanker(opts), nil
+	})
+	rrf := func(opts Options) (Ranker, error) {
+		return NewRRFRanker(opts), nil
+	}
+	Register(StrategyReciprocalRankFusion, rrf)
+	Register(StrategyRRF, rrf)
+	Register(StrategyFeedback, func(opts Options) (Ranker, error) {
+		return NewFeedbackRanker(opts), nil
+	})
+}
+
+// newBase 创建各策略共用的加权评分器
+func newBase(opts Options) *WeightedRanker {
+	r := NewWeightedRanker(opts.Weight, opts.Platforms)
+	if opts.Now != nil {
+		r.SetClock(opts.Now)
+	}
+	return r
+}
+
+// flatten 合并各平台的新闻
+func flatten(data map[string][]*model.NewsItem) []*model.NewsItem {
+	var allItems []*model.NewsItem
+	for _, items := range data {
+		allItems = append(allItems, items...)
+	}
+	return allItems
+}
+
+// sortByKeys 按预先计算的排序键降序排列，键依次比较，全部相同时按平台ID排序保证稳定性
+func sortByKeys(items []*model.NewsItem, keys [][]float64) {
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		ka, kb := keys[idx[a]], keys[idx[b]]
+		for i := range ka {
+			if ka[i] != kb[i] {
+				return ka[i] > kb[i]
+			}
+		}
+		return items[idx[a]].SourceID < items[idx[b]].SourceID
+	})
+
+	sorted := make([]*model.NewsItem, len(items))
+	for i, j := range idx {
+		sorted[i] = items[j]
+	}
+	copy(items, sorted)
+}
+
+// assignPositions 按排序结果写入名次
+func assignPositions(items []*model.NewsItem) {
+	for i, item := range items {
+		if item.Score != nil {
+			item.Score.Position = i + 1
+		}
+	}
+}
+
+// itemAge 返回新闻自首次发现以来的时长，首次发现时间未知时返回0
+func itemAge(item *model.NewsItem, now time.Time) time.Duration {
+	age, _ := firstSeenAge(item, now)
+	return age
+}
+
+// firstSeenAge 返回新闻自首次发现以来的时长，第二个返回值表示首次发现时间是否已知
+// 优先使用完整的 FirstSeenAt；旧数据只有精确到分钟的 FirstSeen（15:04），晚于当前时间的视为前一天
+func firstSeenAge(item *model.NewsItem, now time.Time) (time.Duration, bool) {
+	if !item.FirstSeenAt.IsZero() {
+		if age := now.Sub(item.FirstSeenAt); age > 0 {
+			return age, true
+		}
+		return 0, true
+	}
+
+	t, err := time.ParseInLocation("15:04", item.FirstSeen, now.Location())
+	if err != nil {
+		return 0, false
+	}
+	seen := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if seen.After(now) {
+		seen = seen.AddDate(0, 0, -1)
+	}
+	return now.Sub(seen), true
+}
+
+// firstRank 返回新闻在平台榜单上的排名，缺失时视为第1名
+func firstRank(item *model.NewsItem) int {
+	rank := 1
+	if len(item.Ranks) > 0 {
+		rank = item.Ranks[0]
+	}
+	if rank < 1 {
+		rank = 1
+	}
+	return rank
+}
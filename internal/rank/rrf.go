@@ -0,0 +1,93 @@
+package rank
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// RRFRanker 跨平台的倒数排名融合（Reciprocal Rank Fusion）
+// 同一事件在多个平台上榜时，各平台排名的贡献相加：分数 = Σ 平台权重 / (k + 平台排名)
+// 不依赖各平台热度的绝对数值，适合多平台热榜互相印证的场景
+type RRFRanker struct {
+	base      *WeightedRanker
+	k         float64
+	platforms map[string]float64
+}
+
+// NewRRFRanker 创建倒数排名融合排序
+func NewRRFRanker(opts Options) *RRFRanker {
+	k := opts.Weight.RRFK
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	base := newBase(opts)
+	return &RRFRanker{
+		base:      base,
+		k:         k,
+		platforms: base.platforms,
+	}
+}
+
+func (r *RRFRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	now := r.base.now()
+
+	// 按标题归并同一事件（与跨平台共识使用相同的相似度判断），每个平台只取最好的排名
+	// 开启 rank_normalize 时使用按榜单长度归一化后的排名
+	clusters := r.base.clusters(allItems)
+	scale := newRankScale(r.base.cfg, allItems)
+	best := make(map[int]map[string]float64)
+	for i, item := range allItems {
+		key := clusters[i]
+		ranks, ok := best[key]
+		if !ok {
+			ranks = make(map[string]float64)
+			best[key] = ranks
+		}
+		rank, _ := scale.rank(item)
+		if prev, ok := ranks[item.SourceID]; !ok || rank < prev {
+			ranks[item.SourceID] = rank
+		}
+	}
+
+	fused := make(map[int]float64, len(best))
+	for key, ranks := range best {
+		score := 0.0
+		for platform, rank := range ranks {
+			weight, ok := r.platforms[platform]
+			if !ok {
+				weight = 1.0
+			}
+			score += weight / (r.k + rank)
+		}
+		fused[key] = score
+	}
+
+	scores := r.base.scoreAll(allItems, now)
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		score := scores[i]
+		score.Strategy = StrategyReciprocalRankFusion
+		score.Fusion = fused[clusters[i]]
+		score.Total = score.Fusion
+		item.Score = score
+		keys[i] = []float64{score.Fusion, item.MatchScore, score.Weighted}
+	}
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
+	return allItems
+}
+
+// normalizeTitle 去掉空白和标点并转小写，用于判断不同平台上的标题是否为同一事件
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+package rank
+
+import (
+	"math"
+
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// RecencyDecayRanker 加权分按首次发现时间指数衰减，适合只关心最新动态的场景
+// 分数 = 加权分 × 0.5^(已出现小时数 / 半衰期)
+type RecencyDecayRanker struct {
+	base     *WeightedRanker
+	halfLife float64 // 小时
+}
+
+// NewRecencyDecayRanker 创建时间衰减排序
+func NewRecencyDecayRanker(opts Options) *RecencyDecayRanker {
+	halfLife := opts.Weight.DecayHalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultDecayHalfLife
+	}
+	return &RecencyDecayRanker{
+		base:     newBase(opts),
+		halfLife: halfLife,
+	}
+}
+
+func (r *RecencyDecayRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	now := r.base.now()
+	scores := r.base.scoreAll(allItems, now)
+
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		age := itemAge(item, now).Hours()
+		score := scores[i]
+		score.Strategy = StrategyRecencyDecay
+		score.Decay = math.Pow(0.5, age/r.halfLife)
+		score.Total = score.Weighted * score.Decay
+		item.Score = score
+		keys[i] = []float64{score.Total}
+	}
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
+	return allItems
+}
+
+// VelocityRanker 优先展示上升最快的新闻：刚出现就排名靠前的内容排在前面
+// 速度 = 排名分 / (1 + 已出现小时数)，速度相同时按加权分排序
+type VelocityRanker struct {
+	base *WeightedRanker
+}
+
+// NewVelocityRanker 创建速度优先排序
+func NewVelocityRanker(opts Options) *VelocityRanker {
+	return &VelocityRanker{
+		base: newBase(opts),
+	}
+}
+
+func (r *VelocityRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	now := r.base.now()
+	scores := r.base.scoreAll(allItems, now)
+	scale := newRankScale(r.base.cfg, allItems)
+
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		rankScore := scale.score(item)
+		score := scores[i]
+		score.Strategy = StrategyVelocityFirst
+		score.Velocity = rankScore / (1 + itemAge(item, now).Hours())
+		score.Total = score.Velocity
+		item.Score = score
+		keys[i] = []float64{score.Velocity, score.Weighted}
+	}
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
+	return allItems
+}
+
+// KeywordPriorityRanker 严格按匹配关键词组的优先级排序，其次是关键词匹配分，最后是加权分
+// 适合“重点话题必须排在最前面”的场景
+type KeywordPriorityRanker struct {
+	base       *WeightedRanker
+	priorities []int // 关键词组索引 -> 优先级
+}
+
+// NewKeywordPriorityRanker 创建关键词优先级优先排序
+func NewKeywordPriorityRanker(opts Options) *KeywordPriorityRanker {
+	priorities := make([]int, len(opts.Groups))
+	for i, group := range opts.Groups {
+		priorities[i] = group.Priority
+		if priorities[i] <= 0 {
+			priorities[i] = DefaultPriority
+		}
+	}
+	return &KeywordPriorityRanker{
+		base:       newBase(opts),
+		priorities: priorities,
+	}
+}
+
+func (r *KeywordPriorityRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	now := r.base.now()
+	scores := r.base.scoreAll(allItems, now)
+
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		score := scores[i]
+		score.Strategy = StrategyKeywordPriorityFirst
+		score.Priority = r.priority(item)
+		score.Total = score.Weighted
+		item.Score = score
+		keys[i] = []float64{float64(score.Priority), item.MatchScore, score.Weighted}
+	}
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
+	return allItems
+}
+
+// priority 返回新闻匹配的关键词组优先级，未匹配任何组时为0
+func (r *KeywordPriorityRanker) priority(item *model.NewsItem) int {
+	if item.MatchScore <= 0 || item.KeywordGroup < 0 || item.KeywordGroup >= len(r.priorities) {
+		return 0
+	}
+	return r.priorities[item.KeywordGroup]
+}
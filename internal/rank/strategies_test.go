@@ -0,0 +1,98 @@
+package rank
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/model"
+)
+
+// strategyItems 每个平台一条新闻，按默认权重（rank_weight=1，关键词 0.3，时效性 0.1）的加权分为：
+// toutiao 110、weibo 103.3、baidu 67、zhihu 46.3、douyin 30.1；zhihu 和 douyin 是同一事件
+func strategyItems(now time.Time) map[string][]*model.NewsItem {
+	item := func(title, source string, rank int, age time.Duration, match float64, group int) *model.NewsItem {
+		return &model.NewsItem{
+			Title:        title,
+			SourceID:     source,
+			Ranks:        []int{rank},
+			FirstSeenAt:  now.Add(-age),
+			MatchScore:   match,
+			KeywordGroup: group,
+		}
+	}
+	return map[string][]*model.NewsItem{
+		"weibo":   {item("早上的热搜", "weibo", 1, 10*time.Hour, 10, 0)},
+		"zhihu":   {item("两个平台都在讨论的事件", "zhihu", 3, 0, 10, 1)},
+		"baidu":   {item("命中多个关键词", "baidu", 2, 2*time.Hour, 40, 0)},
+		"toutiao": {item("没有匹配关键词", "toutiao", 1, 0, 0, -1)},
+		"douyin":  {item("两个平台都在讨论的事件！", "douyin", 5, time.Hour, 10, 1)},
+	}
+}
+
+func sourceOrder(items []*model.NewsItem) string {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.SourceID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestStrategyOrdering(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		strategy string
+		want     string
+	}{
+		{"", "toutiao weibo baidu zhihu douyin"},
+		{StrategyWeighted, "toutiao weibo baidu zhihu douyin"},
+		// 加权分 × 0.5^(小时数/6)：出现 10 小时的 weibo 从第2名降到第4名
+		{StrategyRecencyDecay, "toutiao baidu zhihu weibo douyin"},
+		// 排名分 / (1 + 小时数)：100、33.3、16.7、10、9.1
+		{StrategyVelocityFirst, "toutiao zhihu baidu douyin weibo"},
+		// 组优先级 9 的在前，优先级 2 的按匹配分，未匹配关键词的最后
+		{StrategyKeywordPriorityFirst, "zhihu douyin baidu weibo toutiao"},
+		// 同一事件两个平台的贡献相加排在最前；weibo 与 toutiao 都是第1名，融合分相同时按匹配分
+		{StrategyReciprocalRankFusion, "zhihu douyin weibo toutiao baidu"},
+		{" RRF ", "zhihu douyin weibo toutiao baidu"},
+	}
+
+	for _, tt := range tests {
+		r, err := New(Options{
+			Weight: config.WeightConfig{Strategy: tt.strategy, RankWeight: 1},
+			Groups: []config.KeywordGroup{{Priority: 2}, {Priority: 9}},
+			Now:    func() time.Time { return now },
+		})
+		if err != nil {
+			t.Errorf("%q: %v", tt.strategy, err)
+			continue
+		}
+		ranked := r.Rank(strategyItems(now))
+		if got := sourceOrder(ranked); got != tt.want {
+			var scores []string
+			for _, item := range ranked {
+				scores = append(scores, fmt.Sprintf("%s=%.2f", item.SourceID, item.Score.Total))
+			}
+			t.Errorf("%q: order = %s, want %s (%s)", tt.strategy, got, tt.want, strings.Join(scores, ", "))
+		}
+		for i, item := range ranked {
+			if item.Score == nil || item.Score.Position != i+1 {
+				t.Errorf("%q: item %d has score %+v", tt.strategy, i+1, item.Score)
+			}
+		}
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	_, err := New(Options{Weight: config.WeightConfig{Strategy: "pagerank"}})
+	if err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+	for _, name := range []string{"pagerank", StrategyWeighted, StrategyRRF} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q should mention %s", err, name)
+		}
+	}
+}
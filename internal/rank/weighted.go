@@ -1,19 +1,17 @@
 package rank
 
 import (
-	"sort"
+	"math"
+	"time"
 
 	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/model"
 )
 
-type Ranker interface {
-	Rank(items map[string][]*model.NewsItem) []*model.NewsItem
-}
-
 type WeightedRanker struct {
 	cfg       config.WeightConfig
 	platforms map[string]float64 // 平台ID到权重的映射
+	now       func() time.Time   // 时钟，计算时效性分时使用
 }
 
 func NewWeightedRanker(cfg config.WeightConfig, platforms []model.Platform) *WeightedRanker {
@@ -29,41 +27,64 @@ func NewWeightedRanker(cfg config.WeightConfig, platforms []model.Platform) *Wei
 	return &WeightedRanker{
 		cfg:       cfg,
 		platforms: platformWeights,
+		now:       time.Now,
 	}
 }
 
-func (r *WeightedRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
-	var allItems []*model.NewsItem
+// SetClock 设置时钟，回放历史数据时用于按当时的时间计算时效性分
+func (r *WeightedRanker) SetClock(now func() time.Time) {
+	r.now = now
+}
 
-	for _, items := range data {
-		allItems = append(allItems, items...)
+func (r *WeightedRanker) Rank(data map[string][]*model.NewsItem) []*model.NewsItem {
+	allItems := flatten(data)
+	scores := r.scoreAll(allItems, r.now())
+
+	// 每条新闻只计算一次分数
+	keys := make([][]float64, len(allItems))
+	for i, item := range allItems {
+		score := scores[i]
+		score.Strategy = StrategyWeighted
+		score.Total = score.Weighted
+		item.Score = score
+		keys[i] = []float64{score.Total}
 	}
-
-	sort.Slice(allItems, func(i, j int) bool {
-		scoreI := r.calculateScore(allItems[i])
-		scoreJ := r.calculateScore(allItems[j])
-
-		// 分数高的在前
-		if scoreI != scoreJ {
-			return scoreI > scoreJ
-		}
-		// 分数相同，按ID排序保证稳定性
-		return allItems[i].SourceID < allItems[j].SourceID
-	})
+	sortByKeys(allItems, keys)
+	assignPositions(allItems)
 
 	return allItems
 }
 
-func (r *WeightedRanker) calculateScore(item *model.NewsItem) float64 {
-	// 1. 排名分 (0-100)
-	rank := 1
-	if len(item.Ranks) > 0 {
-		rank = item.Ranks[0]
+// scoreAll 计算一批新闻的加权分，启用跨平台共识时先按标题归并同一事件
+func (r *WeightedRanker) scoreAll(items []*model.NewsItem, now time.Time) []*model.ScoreBreakdown {
+	var clusters []int
+	var stories map[int]*story
+	if r.cfg.ConsensusWeight > 0 {
+		clusters = r.clusters(items)
+		stories = buildStories(items, clusters, r.platforms)
 	}
-	if rank < 1 {
-		rank = 1
+	scale := newRankScale(r.cfg, items)
+
+	scores := make([]*model.ScoreBreakdown, len(items))
+	for i, item := range items {
+		var s *story
+		if stories != nil {
+			s = stories[clusters[i]]
+		}
+		scores[i] = r.calculateScore(item, now, s, scale)
 	}
-	rankScore := 1.0 / float64(rank) * 100.0 // 归一化到0-100区间
+	return scores
+}
+
+// clusters 按标题归并不同平台上的同一事件，返回每条新闻所属的组号
+func (r *WeightedRanker) clusters(items []*model.NewsItem) []int {
+	return clusterStories(items, r.cfg.ConsensusSimilarity)
+}
+
+// calculateScore 计算加权总分及各分项贡献，s 为新闻所属的跨平台事件，未启用共识时为 nil
+func (r *WeightedRanker) calculateScore(item *model.NewsItem, now time.Time, s *story, scale *rankScale) *model.ScoreBreakdown {
+	// 1. 排名分 (0-100) - 按 rank_curve 换算，开启 rank_normalize 时先按榜单长度归一化
+	rankScore := scale.score(item)
 
 	// 2. 频次分 (0-100)
 	freqScore := float64(item.AppearCount)
@@ -86,47 +107,77 @@ func (r *WeightedRanker) calculateScore(item *model.NewsItem) float64 {
 		keywordScore = 100
 	}
 
-	// 5. 时效性分 (0-100) - 新出现的内容加分
+	// 5. 时效性分 (0-100) - 按首次发现时间指数衰减：刚出现满分，每经过一个半衰期减半
 	freshnessScore := 0.0
-	if item.IsNew {
-		freshnessScore = 100.0 // 新内容满分
+	if age, ok := firstSeenAge(item, now); ok {
+		halfLife := r.cfg.FreshnessHalfLife
+		if halfLife <= 0 {
+			halfLife = DefaultFreshnessHalfLife
+		}
+		freshnessScore = 100.0 * math.Pow(0.5, age.Hours()/halfLife)
+	} else if item.IsNew {
+		freshnessScore = 100.0 // 首次发现时间未知时沿用是否新增
+	}
+
+	// 6. 跨平台共识分 (0-100) - 其他平台上榜越多、平台权重越高分数越高
+	// 分数 = 100 × W / (W + 1)，W 为同一事件所在其他平台的权重之和，只在单个平台上榜时为0
+	consensusScore := 0.0
+	if s != nil {
+		others := s.weight - r.platformWeight(item.SourceID)
+		if others > 0 {
+			consensusScore = 100.0 * others / (others + 1)
+		}
 	}
 
 	// 获取关键词权重，如果配置为0则使用默认值
 	keywordWeight := r.cfg.KeywordWeight
 	if keywordWeight == 0 {
-		keywordWeight = 0.3 // 默认关键词权重
+		keywordWeight = DefaultKeywordWeight
 	}
 
 	freshnessWeight := r.cfg.FreshnessWeight
 	if freshnessWeight == 0 {
-		freshnessWeight = 0.1 // 默认时效性权重
+		freshnessWeight = DefaultFreshnessWeight
 	}
 
 	// 加权总分
-	totalScore := rankScore*r.cfg.RankWeight +
-		freqScore*r.cfg.FrequencyWeight +
-		hotnessScore*r.cfg.HotnessWeight +
-		keywordScore*keywordWeight +
-		freshnessScore*freshnessWeight
-
-	// 6. 应用平台权重 (0-1) - 方案二的核心
-	platformWeight, exists := r.platforms[item.SourceID]
-	if !exists {
-		platformWeight = 1.0 // 默认权重
+	score := &model.ScoreBreakdown{
+		Rank:      rankScore * r.cfg.RankWeight,
+		Frequency: freqScore * r.cfg.FrequencyWeight,
+		Hotness:   hotnessScore * r.cfg.HotnessWeight,
+		Keyword:   keywordScore * keywordWeight,
+		Freshness: freshnessScore * freshnessWeight,
+		Consensus: consensusScore * r.cfg.ConsensusWeight,
+	}
+	score.Base = score.Rank + score.Frequency + score.Hotness + score.Keyword + score.Freshness + score.Consensus
+	if s != nil && len(s.platforms) > 1 {
+		score.ConsensusPlatforms = s.platforms
 	}
 
+	// 7. 应用平台权重 (0-1) - 方案二的核心
+	platformWeight := r.platformWeight(item.SourceID)
+
 	// 平台权重影响系数
 	platformWeightEffect := r.cfg.PlatformWeight
 	if platformWeightEffect == 0 {
-		platformWeightEffect = 1.0 // 默认完全应用平台权重
+		platformWeightEffect = DefaultPlatformWeightEffect // 默认完全应用平台权重
 	}
 
 	// 应用平台权重：基础分数 * (1 + (platformWeight - 1) * effect)
 	// 这样当 platformWeight=1.0 时，分数不变
 	// 当 platformWeight=0.8 时，分数会减少
 	// 当 platformWeight=1.2 时，分数会增加
-	totalScore = totalScore * (1.0 + (platformWeight-1.0)*platformWeightEffect)
+	score.PlatformMultiplier = 1.0 + (platformWeight-1.0)*platformWeightEffect
+	score.Weighted = score.Base * score.PlatformMultiplier
 
-	return totalScore
+	return score
+}
+
+// platformWeight 返回平台权重，未配置的平台为1.0
+func (r *WeightedRanker) platformWeight(id string) float64 {
+	weight, ok := r.platforms[id]
+	if !ok {
+		return 1.0 // 默认权重
+	}
+	return weight
 }
@@ -0,0 +1,251 @@
+// Package rankeval 用历史抓取数据对比不同排序配置的结果
+package rankeval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/filter"
+	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/rank"
+	"github.com/gotoailab/trendhub/internal/watchlist"
+)
+
+// Variant 一组待评估的配置：关键词规则和排序参数
+type Variant struct {
+	Name   string
+	Config *config.GlobalConfig
+	Boosts *rank.Boosts // feedback 策略使用的反馈系数，可为空
+
+	filter *filter.KeywordFilter
+}
+
+// NewVariant 按配置构建关键词过滤器，外部词表在此时加载
+func NewVariant(ctx context.Context, name string, cfg *config.GlobalConfig, lists *watchlist.Store) (*Variant, error) {
+	f := filter.NewKeywordFilter(lists.Resolve(ctx, cfg.KeywordGroups), cfg.GlobalFilters)
+	if err := f.SetURLRules(cfg.Config.Filter.URLRules); err != nil {
+		return nil, fmt.Errorf("invalid filter.url_rules: %w", err)
+	}
+	if cfg.Config.Filter.Semantic.Enabled {
+		m, err := filter.NewSemanticMatcher(cfg.Config.Filter.Semantic)
+		if err != nil {
+			return nil, fmt.Errorf("semantic matching: %w", err)
+		}
+		f.SetSemantic(m)
+	}
+	return &Variant{Name: name, Config: cfg, filter: f}, nil
+}
+
+// Rank 过滤并排序一次抓取的数据，now 为当时的抓取时间，用于计算时效性
+// data 中的新闻会被修改，对比多个配置时每个配置需使用独立的数据副本
+func (v *Variant) Rank(data map[string][]*model.NewsItem, now time.Time) ([]*model.NewsItem, error) {
+	filtered, err := v.filter.Filter(data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := rank.New(rank.Options{
+		Weight:    v.Config.Config.Weight,
+		Platforms: v.Config.Config.Platforms,
+		Groups:    v.Config.KeywordGroups,
+		Now:       func() time.Time { return now },
+		Boosts:    v.Boosts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Rank(filtered), nil
+}
+
+// Move 一条新闻在基准结果（From）和候选结果（To）中的名次，0 表示未通过关键词过滤
+type Move struct {
+	Title    string `json:"title"`
+	SourceID string `json:"source_id"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+}
+
+// Comparison 两个排序结果前 N 条的差异
+type Comparison struct {
+	Top      int     `json:"top"`
+	Overlap  float64 `json:"overlap"`  // 共同出现的条数 / 前 N 条的条数
+	Spearman float64 `json:"spearman"` // 名次的 Spearman 相关系数，未出现的新闻按第 N+1 名计
+	Surfaced []Move  `json:"surfaced"` // 新进入前 N 条的新闻
+	Dropped  []Move  `json:"dropped"`  // 跌出前 N 条的新闻
+	Moved    []Move  `json:"moved"`    // 两边都在前 N 条、名次变化最大的新闻
+}
+
+// MaxMoved Comparison.Moved 最多列出的条数
+const MaxMoved = 5
+
+// Compare 对比基准结果 base 与候选结果 alt 的前 top 条
+func Compare(base, alt []*model.NewsItem, top int) *Comparison {
+	// 完整名次：跌出或新进入前 N 条的新闻在另一边的实际名次，被关键词过滤掉时为0
+	baseFull := positions(base, len(base))
+	altFull := positions(alt, len(alt))
+	a := positions(base, top)
+	b := positions(alt, top)
+
+	c := &Comparison{Top: top}
+	size := len(a)
+	if len(b) > size {
+		size = len(b)
+	}
+	if size == 0 {
+		c.Overlap, c.Spearman = 1, 1
+		return c
+	}
+
+	common := 0
+	for key, from := range a {
+		to, ok := b[key]
+		if !ok {
+			c.Dropped = append(c.Dropped, Move{Title: titleOf(base, from), SourceID: sourceOf(base, from), From: from, To: altFull[key]})
+			continue
+		}
+		common++
+		if from != to {
+			c.Moved = append(c.Moved, Move{Title: titleOf(base, from), SourceID: sourceOf(base, from), From: from, To: to})
+		}
+	}
+	for key, to := range b {
+		if _, ok := a[key]; !ok {
+			c.Surfaced = append(c.Surfaced, Move{Title: titleOf(alt, to), SourceID: sourceOf(alt, to), From: baseFull[key], To: to})
+		}
+	}
+	c.Overlap = float64(common) / float64(size)
+	c.Spearman = spearman(a, b, top)
+
+	sort.Slice(c.Surfaced, func(i, j int) bool { return c.Surfaced[i].To < c.Surfaced[j].To })
+	sort.Slice(c.Dropped, func(i, j int) bool { return c.Dropped[i].From < c.Dropped[j].From })
+	sort.Slice(c.Moved, func(i, j int) bool {
+		di, dj := abs(c.Moved[i].From-c.Moved[i].To), abs(c.Moved[j].From-c.Moved[j].To)
+		if di != dj {
+			return di > dj
+		}
+		return c.Moved[i].From < c.Moved[j].From
+	})
+	if len(c.Moved) > MaxMoved {
+		c.Moved = c.Moved[:MaxMoved]
+	}
+	return c
+}
+
+// itemKey 新闻标识：平台 + 标题
+func itemKey(item *model.NewsItem) string {
+	return item.SourceID + "|" + item.Title
+}
+
+// positions 返回前 n 条新闻的名次（从1开始）
+func positions(items []*model.NewsItem, n int) map[string]int {
+	if n > len(items) {
+		n = len(items)
+	}
+	pos := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		key := itemKey(items[i])
+		if _, ok := pos[key]; !ok {
+			pos[key] = i + 1
+		}
+	}
+	return pos
+}
+
+func titleOf(items []*model.NewsItem, position int) string {
+	return items[position-1].Title
+}
+
+func sourceOf(items []*model.NewsItem, position int) string {
+	return items[position-1].SourceID
+}
+
+// spearman 计算两组名次在并集上的 Spearman 相关系数
+// 只出现在一边的新闻在另一边按第 top+1 名计，并列名次取平均后按 Pearson 公式计算
+func spearman(a, b map[string]int, top int) float64 {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < 2 {
+		return 1
+	}
+
+	x := make([]float64, len(keys))
+	y := make([]float64, len(keys))
+	for i, key := range keys {
+		x[i] = rankOr(a, key, top)
+		y[i] = rankOr(b, key, top)
+	}
+	return pearson(averageRanks(x), averageRanks(y))
+}
+
+func rankOr(pos map[string]int, key string, top int) float64 {
+	if p, ok := pos[key]; ok {
+		return float64(p)
+	}
+	return float64(top + 1)
+}
+
+// averageRanks 将数值转为名次，并列时取平均名次
+func averageRanks(values []float64) []float64 {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return values[idx[i]] < values[idx[j]] })
+
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && values[idx[j+1]] == values[idx[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[idx[k]] = avg
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= n
+	my /= n
+
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		if vx == vy {
+			return 1
+		}
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -3,16 +3,24 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gotoailab/trendhub/config"
 	"github.com/gotoailab/trendhub/internal/logger"
+	"github.com/gotoailab/trendhub/internal/model"
 	"github.com/gotoailab/trendhub/internal/pushdb"
 )
 
+// TaskResult 任务的执行结果
+type TaskResult struct {
+	ItemCount  int              // 推送的条目数量
+	Deliveries []model.Delivery // 各推送渠道的发送结果，没有推送时为空
+}
+
 // TaskFunc 任务执行函数
-type TaskFunc func() (int, error) // 返回推送的条目数量和错误
+type TaskFunc func() (*TaskResult, error)
 
 // Scheduler 定时调度器
 type Scheduler struct {
@@ -266,25 +274,48 @@ func (s *Scheduler) executeTask() {
 	recordID := fmt.Sprintf("%d", time.Now().UnixNano())
 	startTime := time.Now()
 
-	itemCount, err := s.taskFunc()
+	result, err := s.taskFunc()
 	duration := time.Since(startTime).Milliseconds()
+	if result == nil {
+		result = &TaskResult{}
+	}
 
 	record := &pushdb.PushRecord{
-		ID:        recordID,
-		Timestamp: startTime,
-		ItemCount: itemCount,
-		Duration:  duration,
+		ID:         recordID,
+		Timestamp:  startTime,
+		ItemCount:  result.ItemCount,
+		Duration:   duration,
+		Deliveries: result.Deliveries,
 	}
 
-	if err != nil {
+	// 按各渠道的发送结果统计：全部成功为 success，全部失败为 failed，否则为 partial
+	var errs []string
+	for _, d := range result.Deliveries {
+		record.Notifiers = append(record.Notifiers, d.Channel)
+		if d.Status == model.DeliverySuccess {
+			record.SuccessNum++
+		} else {
+			record.FailedNum++
+			errs = append(errs, d.Channel+": "+d.Error)
+		}
+	}
+
+	switch {
+	case err != nil:
 		record.Status = "failed"
 		record.ErrorMsg = err.Error()
-		record.FailedNum = 1
 		logger.Infof("Task failed: %v\n", err)
-	} else {
+	case record.FailedNum == 0:
 		record.Status = "success"
-		record.SuccessNum = 1
-		logger.Infof("Task completed successfully, pushed %d items\n", itemCount)
+		logger.Infof("Task completed successfully, pushed %d items via %d channels\n", result.ItemCount, record.SuccessNum)
+	case record.SuccessNum == 0:
+		record.Status = "failed"
+		record.ErrorMsg = strings.Join(errs, "; ")
+		logger.Infof("Task completed but all %d channels failed\n", record.FailedNum)
+	default:
+		record.Status = "partial"
+		record.ErrorMsg = strings.Join(errs, "; ")
+		logger.Infof("Task completed, %d of %d channels failed\n", record.FailedNum, len(result.Deliveries))
 	}
 
 	// 保存记录
@@ -0,0 +1,286 @@
+package watchlist
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/logger"
+)
+
+// retryInterval 加载失败后的最短重试间隔
+const retryInterval = 5 * time.Minute
+
+// Store 外部词表缓存，按各词表的 refresh 间隔重新加载
+// 刷新是惰性的：没有后台定时任务，每次 Resolve 时检查词表是否过期，过期的在本次调用中重新加载
+type Store struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	client  *http.Client
+}
+
+type entry struct {
+	terms     []string
+	loadedAt  time.Time     // 最近一次成功加载时间
+	checkedAt time.Time     // 最近一次尝试加载时间
+	loading   chan struct{} // 正在加载时不为 nil，加载完成后关闭
+}
+
+// NewStore 创建词表缓存
+func NewStore() *Store {
+	return &Store{
+		entries: make(map[string]*entry),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Resolve 将关键词组引用的外部词表展开为普通词
+// 返回新的关键词组切片，不修改入参；词表加载失败时沿用上一次成功加载的内容
+func (s *Store) Resolve(ctx context.Context, groups []config.KeywordGroup) []config.KeywordGroup {
+	resolved := make([]config.KeywordGroup, len(groups))
+	for i, group := range groups {
+		resolved[i] = group
+		if len(group.Lists) == 0 {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		normal := make([]string, 0, len(group.Normal))
+		for _, w := range group.Normal {
+			seen[strings.ToLower(w)] = true
+			normal = append(normal, w)
+		}
+		for _, list := range group.Lists {
+			for _, term := range s.terms(ctx, list) {
+				key := strings.ToLower(term)
+				if !seen[key] {
+					seen[key] = true
+					normal = append(normal, term)
+				}
+			}
+		}
+		resolved[i].Normal = normal
+	}
+	return resolved
+}
+
+// terms 返回词表内容，过期时重新加载
+// 加载（可能是耗时的 HTTP 请求）不持有 s.mu，同一词表同时只有一个调用在加载：
+// 已有缓存时其他调用直接返回旧内容，首次加载时等待加载完成
+func (s *Store) terms(ctx context.Context, list config.KeywordList) []string {
+	key := cacheKey(list)
+
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if !ok {
+		e = &entry{}
+		s.entries[key] = e
+	}
+
+	if loading := e.loading; loading != nil {
+		if !e.loadedAt.IsZero() {
+			terms := e.terms
+			s.mu.Unlock()
+			return terms
+		}
+		s.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return e.terms
+	}
+
+	now := time.Now()
+	refresh := list.Refresh
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	stale := e.loadedAt.IsZero() || now.Sub(e.loadedAt) >= refresh
+	// 失败后避免每次过滤都重试
+	retrying := !e.checkedAt.IsZero() && now.Sub(e.checkedAt) < retryInterval && now.Sub(e.checkedAt) < refresh
+	if !stale || retrying {
+		terms := e.terms
+		s.mu.Unlock()
+		return terms
+	}
+
+	e.checkedAt = now
+	loading := make(chan struct{})
+	e.loading = loading
+	s.mu.Unlock()
+
+	terms, err := s.load(ctx, list)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.loading = nil
+	close(loading)
+	if err != nil {
+		logger.Errorf("Failed to load keyword list %s: %v (keeping %d cached terms)", list.Source, err, len(e.terms))
+		return e.terms
+	}
+	if e.loadedAt.IsZero() || len(terms) != len(e.terms) {
+		logger.Infof("Keyword list %s loaded: %d terms", list.Source, len(terms))
+	}
+	e.terms = terms
+	e.loadedAt = now
+	return e.terms
+}
+
+// load 读取并解析词表
+func (s *Store) load(ctx context.Context, list config.KeywordList) ([]string, error) {
+	data, err := s.read(ctx, list.Source)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, list)
+}
+
+// read 读取本地文件或远程 URL
+func (s *Store) read(ctx context.Context, source string) ([]byte, error) {
+	if !isURL(source) {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Parse 按词表格式解析内容，返回去除空白后的词条
+func Parse(data []byte, list config.KeywordList) ([]string, error) {
+	// 去掉 UTF-8 BOM（Excel 导出的 CSV 常见）
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch format(list) {
+	case "txt":
+		return parseLines(data), nil
+	case "tsv":
+		return parseCSV(data, '\t', list)
+	default:
+		return parseCSV(data, ',', list)
+	}
+}
+
+// parseLines 每行一个词，忽略空行和 # 注释
+func parseLines(data []byte) []string {
+	var terms []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms
+}
+
+// parseCSV 取指定列作为词条
+func parseCSV(data []byte, comma rune, list config.KeywordList) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv failed: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	col := 0
+	if list.Column != "" {
+		if n, err := strconv.Atoi(list.Column); err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("column index must start from 1")
+			}
+			col = n - 1
+		} else {
+			col = -1
+			for i, name := range records[0] {
+				if strings.TrimSpace(name) == list.Column {
+					col = i
+					break
+				}
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("column %q not found in header", list.Column)
+			}
+		}
+	}
+
+	if list.Header {
+		records = records[1:]
+	}
+
+	var terms []string
+	for _, record := range records {
+		if col >= len(record) {
+			continue
+		}
+		term := strings.TrimSpace(record[col])
+		if term == "" {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// format 返回词表格式，未显式指定时按扩展名识别
+func format(list config.KeywordList) string {
+	if list.Format != "" {
+		return list.Format
+	}
+	source := list.Source
+	if isURL(source) {
+		if i := strings.IndexAny(source, "?#"); i >= 0 {
+			source = source[:i]
+		}
+	}
+	switch strings.ToLower(path.Ext(source)) {
+	case ".csv":
+		return "csv"
+	case ".tsv":
+		return "tsv"
+	default:
+		return "txt"
+	}
+}
+
+func cacheKey(list config.KeywordList) string {
+	return fmt.Sprintf("%s|%s|%v|%s", list.Source, list.Column, list.Header, list.Format)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
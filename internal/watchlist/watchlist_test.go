@@ -0,0 +1,137 @@
+package watchlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotoailab/trendhub/config"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		list config.KeywordList
+		want []string
+	}{
+		{"txt", "\xef\xbb\xbf# 注释\n华为\n\n 小米 \n", config.KeywordList{Source: "a.txt"}, []string{"华为", "小米"}},
+		{"csv column name", "代码,简称\n600519,贵州茅台\n300750, 宁德时代\n", config.KeywordList{Source: "a.csv", Column: "简称", Header: true}, []string{"贵州茅台", "宁德时代"}},
+		{"csv column index", "600519,贵州茅台\n300750\n", config.KeywordList{Source: "a.csv", Column: "2"}, []string{"贵州茅台"}},
+		{"tsv", "a\tb\nc\td\n", config.KeywordList{Source: "https://example.com/a.tsv?v=1"}, []string{"a", "c"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse([]byte(tt.data), tt.list)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTermsFirstLoadShared(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Write([]byte("华为\n小米\n"))
+	}))
+	defer server.Close()
+	s := NewStore()
+	list := config.KeywordList{Source: server.URL + "/list.txt"}
+
+	var wg sync.WaitGroup
+	results := make([][]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.terms(context.Background(), list)
+		}(i)
+	}
+	// 等所有调用都开始后再返回词表
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("list fetched %d times, want 1", n)
+	}
+	for i, terms := range results {
+		if strings.Join(terms, ",") != "华为,小米" {
+			t.Errorf("call %d got %v", i, terms)
+		}
+	}
+}
+
+func TestTermsRefreshDoesNotBlock(t *testing.T) {
+	// 第1次请求返回旧词表，第2次（刷新）挂起到 release 关闭，之后的请求立即返回新词表
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.Write([]byte("华为\n"))
+		case 2:
+			<-release
+			fallthrough
+		default:
+			w.Write([]byte("华为\n小米\n"))
+		}
+	}))
+	defer server.Close()
+	s := NewStore()
+	list := config.KeywordList{Source: server.URL + "/list.txt", Refresh: time.Millisecond}
+
+	if got := s.terms(context.Background(), list); len(got) != 1 {
+		t.Fatalf("first load got %v", got)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	refreshed := make(chan []string)
+	go func() { refreshed <- s.terms(context.Background(), list) }()
+	for hits.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 刷新请求挂起期间，其他调用直接返回旧内容，其他词表也可以加载
+	done := make(chan []string)
+	go func() { done <- s.terms(context.Background(), list) }()
+	select {
+	case got := <-done:
+		if strings.Join(got, ",") != "华为" {
+			t.Errorf("stale terms = %v, want the cached list", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("terms blocked while another call was refreshing the list")
+	}
+	go func() { done <- s.terms(context.Background(), config.KeywordList{Source: server.URL + "/other.txt"}) }()
+	select {
+	case got := <-done:
+		if len(got) != 2 {
+			t.Errorf("other list = %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("loading another list blocked while a list was refreshing")
+	}
+
+	close(release)
+	if got := <-refreshed; strings.Join(got, ",") != "华为,小米" {
+		t.Errorf("refreshed terms = %v", got)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("lists fetched %d times, want 3", n)
+	}
+}
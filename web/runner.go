@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,12 +14,15 @@ import (
 	"github.com/gotoailab/trendhub/internal/collector"
 	"github.com/gotoailab/trendhub/internal/crawler"
 	"github.com/gotoailab/trendhub/internal/datacache"
+	"github.com/gotoailab/trendhub/internal/feedback"
 	"github.com/gotoailab/trendhub/internal/filter"
 	"github.com/gotoailab/trendhub/internal/model"
 	"github.com/gotoailab/trendhub/internal/notifier"
+	"github.com/gotoailab/trendhub/internal/outbox"
 	"github.com/gotoailab/trendhub/internal/pushdb"
 	"github.com/gotoailab/trendhub/internal/rank"
 	"github.com/gotoailab/trendhub/internal/scheduler"
+	"github.com/gotoailab/trendhub/internal/watchlist"
 )
 
 // TaskRunner 负责执行任务
@@ -29,12 +33,19 @@ type TaskRunner struct {
 	DataCache      *datacache.DataCache
 	Scheduler      *scheduler.Scheduler
 	DailyCollector *collector.DailyCollector
+	Outbox         *outbox.Retrier  // 重试发送失败的推送
+	Watchlists     *watchlist.Store // 关键词组引用的外部词表缓存
 	mu             sync.Mutex
 	IsRunning      bool
 	LastLog        string
 	LastRunTime    time.Time
 	ExtraWriter    io.Writer // 额外的日志输出目标（如 os.Stdout）
 	logFilePath    string    // 日志文件路径
+
+	filterMu       sync.Mutex
+	keywordFilter  *filter.KeywordFilter   // 跨任务复用的关键词过滤器（含预构建的自动机）
+	semantic       *filter.SemanticMatcher // 跨任务复用的语义匹配器（含向量缓存）
+	semanticConfig config.SemanticConfig   // semantic 对应的配置
 }
 
 func NewTaskRunner(configPath, keywordPath string, pushDB *pushdb.PushDB, dataCache *datacache.DataCache) *TaskRunner {
@@ -43,6 +54,7 @@ func NewTaskRunner(configPath, keywordPath string, pushDB *pushdb.PushDB, dataCa
 		KeywordPath: keywordPath,
 		PushDB:      pushDB,
 		DataCache:   dataCache,
+		Watchlists:  watchlist.NewStore(),
 	}
 }
 
@@ -56,6 +68,173 @@ func (tr *TaskRunner) GetLogFilePath() string {
 	return tr.logFilePath
 }
 
+// keywordFilterFor 返回与当前关键词规则一致的过滤器
+// 外部词表先按各自的刷新周期展开；规则未变化时复用已构建的自动机，关键词较多时可避免每次任务重复构建
+func (tr *TaskRunner) keywordFilterFor(ctx context.Context, cfg *config.GlobalConfig) (*filter.KeywordFilter, error) {
+	groups := tr.Watchlists.Resolve(ctx, cfg.KeywordGroups)
+	urlRules := cfg.Config.Filter.URLRules
+
+	tr.filterMu.Lock()
+	defer tr.filterMu.Unlock()
+
+	semantic := tr.semanticFor(cfg.Config.Filter.Semantic)
+	if tr.keywordFilter == nil || !tr.keywordFilter.SameRules(groups, cfg.GlobalFilters, urlRules, semantic) {
+		f := filter.NewKeywordFilter(groups, cfg.GlobalFilters)
+		if err := f.SetURLRules(urlRules); err != nil {
+			return nil, fmt.Errorf("invalid filter.url_rules: %w", err)
+		}
+		f.SetSemantic(semantic)
+		tr.keywordFilter = f
+	}
+	return tr.keywordFilter, nil
+}
+
+// semanticFor 返回与配置一致的语义匹配器，未启用或配置有误时返回 nil（只进行字面匹配）
+// 调用方需持有 filterMu
+func (tr *TaskRunner) semanticFor(cfg config.SemanticConfig) *filter.SemanticMatcher {
+	if cfg == tr.semanticConfig {
+		return tr.semantic
+	}
+	tr.semanticConfig = cfg
+	tr.semantic = nil
+
+	if !cfg.Enabled {
+		return nil
+	}
+	m, err := filter.NewSemanticMatcher(cfg)
+	if err != nil {
+		log.Printf("Semantic matching disabled: %v", err)
+		return nil
+	}
+	tr.semantic = m
+	return m
+}
+
+// newRanker 按配置的排序策略创建排序器，策略或排名分曲线无效时返回默认的 weighted 排序器和错误
+func (tr *TaskRunner) newRanker(cfg *config.GlobalConfig) (rank.Ranker, error) {
+	opts := rank.Options{
+		Weight:    cfg.Config.Weight,
+		Platforms: cfg.Config.Platforms,
+		Groups:    cfg.KeywordGroups,
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.Config.Weight.Strategy), rank.StrategyFeedback) {
+		m, err := tr.FeedbackModel(cfg.Config)
+		if err != nil {
+			log.Printf("Failed to load feedback, ranking without it: %v", err)
+		} else {
+			opts.Boosts = m.Boosts()
+		}
+	}
+	r, err := rank.New(opts)
+	if err != nil {
+		opts.Weight.Strategy = rank.StrategyWeighted
+		opts.Weight.RankCurve = ""
+		fallback, _ := rank.New(opts)
+		return fallback, err
+	}
+	return r, nil
+}
+
+// FeedbackModel 从最近的阅读反馈中学习平台和关键词组的调整系数
+func (tr *TaskRunner) FeedbackModel(cfg *config.Config) (*feedback.Model, error) {
+	if tr.PushDB == nil {
+		return nil, fmt.Errorf("push database not initialized")
+	}
+	records, err := tr.PushDB.GetFeedbackSince(time.Now().AddDate(0, 0, -feedback.Days(cfg.Feedback)))
+	if err != nil {
+		return nil, err
+	}
+	return feedback.Learn(records, cfg.Feedback), nil
+}
+
+// FeedbackSuggestion 用上次应用建议之后的阅读反馈生成配置调整建议
+func (tr *TaskRunner) FeedbackSuggestion(cfg *config.Config) (*feedback.Suggestion, error) {
+	if tr.PushDB == nil {
+		return nil, fmt.Errorf("push database not initialized")
+	}
+	applied, err := tr.PushDB.FeedbackAppliedAt()
+	if err != nil {
+		return nil, err
+	}
+	records, err := tr.PushDB.GetFeedbackSince(time.Now().AddDate(0, 0, -feedback.Days(cfg.Feedback)))
+	if err != nil {
+		return nil, err
+	}
+	s := feedback.Learn(feedback.Pending(records, applied), cfg.Feedback).Suggest(cfg)
+	if !applied.IsZero() {
+		s.AppliedAt = &applied
+	}
+	return s, nil
+}
+
+// trackLinks 开启链接跟踪时返回链接替换为跟踪链接的新闻副本，否则原样返回
+func (tr *TaskRunner) trackLinks(cfg *config.Config, items []*model.NewsItem) ([]*model.NewsItem, error) {
+	if !cfg.Feedback.TrackLinks || cfg.App.PublicURL == "" || tr.PushDB == nil {
+		return items, nil
+	}
+	secret, err := tr.PushDB.FeedbackSecret()
+	if err != nil {
+		return items, err
+	}
+	return feedback.NewLinker(cfg.App.PublicURL, secret).Wrap(items), nil
+}
+
+// muteLink 配置了 app.public_url 时返回生成关键词组静音链接的函数，否则返回 nil
+func (tr *TaskRunner) muteLink(cfg *config.Config) (func(group string) string, error) {
+	if cfg.App.PublicURL == "" || tr.PushDB == nil {
+		return nil, nil
+	}
+	secret, err := tr.PushDB.FeedbackSecret()
+	if err != nil {
+		return nil, err
+	}
+	linker := feedback.NewLinker(cfg.App.PublicURL, secret)
+	hours := feedback.MuteHours(cfg.Feedback)
+	return func(group string) string { return linker.MuteURL(group, hours) }, nil
+}
+
+// redeliver 按当前配置创建通知器，通过标识为 channelID 的渠道重新发送重试队列中的消息，跳过已发送的 skip 批
+func (tr *TaskRunner) redeliver(channelID string, items []*model.NewsItem, skip int) model.Delivery {
+	cfg, err := config.LoadConfig(tr.ConfigPath, tr.KeywordPath)
+	if err != nil {
+		return model.Delivery{Channel: channelID, ChannelID: channelID, Status: model.DeliveryFailed, Error: fmt.Sprintf("failed to load config: %v", err)}
+	}
+	n := notifier.NewNotificationManager(cfg.Config, cfg.KeywordGroups)
+	if link, err := tr.muteLink(cfg.Config); err == nil && link != nil {
+		n.SetMuteLink(link)
+	}
+	return n.SendTo(channelID, items, skip)
+}
+
+// dropMuted 去掉被静音的关键词组的新闻，返回剩余的新闻和被去掉的条数
+func (tr *TaskRunner) dropMuted(items []*model.NewsItem) ([]*model.NewsItem, int, error) {
+	if tr.PushDB == nil {
+		return items, 0, nil
+	}
+	mutes, err := tr.PushDB.GetMutes()
+	if err != nil || len(mutes) == 0 {
+		return items, 0, err
+	}
+	muted := make(map[string]bool, len(mutes))
+	for _, m := range mutes {
+		muted[m.Group] = true
+	}
+	kept := make([]*model.NewsItem, 0, len(items))
+	for _, item := range items {
+		if item.KeywordGroupKey == "" || !muted[item.KeywordGroupKey] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, len(items) - len(kept), nil
+}
+
+// ResetKeywordFilter 丢弃缓存的关键词过滤器，下次任务时重新构建
+func (tr *TaskRunner) ResetKeywordFilter() {
+	tr.filterMu.Lock()
+	defer tr.filterMu.Unlock()
+	tr.keywordFilter = nil
+}
+
 // StartScheduler 启动定时调度器
 func (tr *TaskRunner) StartScheduler(ctx context.Context) error {
 	cfg, err := config.LoadConfig(tr.ConfigPath, tr.KeywordPath)
@@ -70,22 +249,19 @@ func (tr *TaskRunner) StartScheduler(ctx context.Context) error {
 		log.Println("Daily mode: continuous data collection started")
 	}
 
+	// 重试发送失败的推送，不受推送时间窗口限制
+	if tr.PushDB != nil {
+		tr.Outbox = outbox.NewRetrier(cfg.Config.Notification.Outbox, tr.PushDB, tr.redeliver)
+		tr.Outbox.Start(ctx)
+	}
+
 	if !cfg.Config.Notification.PushWindow.Enabled {
 		log.Println("Push window disabled, scheduler will not start")
 		// 即使不启用定时推送，daily 收集器也会运行
 		return nil
 	}
 
-	// 创建任务函数
-	taskFunc := func() (int, error) {
-		itemCount, err := tr.RunWithCount()
-		if err != nil {
-			return 0, err
-		}
-		return itemCount, nil
-	}
-
-	tr.Scheduler = scheduler.NewScheduler(&cfg.Config.Notification, tr.PushDB, taskFunc)
+	tr.Scheduler = scheduler.NewScheduler(&cfg.Config.Notification, tr.PushDB, tr.RunWithResult)
 	return tr.Scheduler.Start(ctx)
 }
 
@@ -97,6 +273,9 @@ func (tr *TaskRunner) StopScheduler() {
 	if tr.DailyCollector != nil {
 		tr.DailyCollector.Stop()
 	}
+	if tr.Outbox != nil {
+		tr.Outbox.Stop()
+	}
 }
 
 // ReloadConfig 重新加载配置并更新调度器和收集器
@@ -109,6 +288,9 @@ func (tr *TaskRunner) ReloadConfig(ctx context.Context) error {
 		return fmt.Errorf("failed to reload config: %w", err)
 	}
 
+	// 关键词可能已变化，丢弃缓存的过滤器
+	tr.ResetKeywordFilter()
+
 	// 1. 处理 Daily Collector
 	if cfg.Config.Report.Mode == "daily" {
 		if tr.DailyCollector == nil {
@@ -139,37 +321,38 @@ func (tr *TaskRunner) ReloadConfig(ctx context.Context) error {
 	} else if cfg.Config.Notification.PushWindow.Enabled {
 		// 之前没有调度器，但现在启用了，需要创建并启动
 		log.Println("Creating and starting scheduler (push window enabled)...")
-		taskFunc := func() (int, error) {
-			itemCount, err := tr.RunWithCount()
-			return itemCount, err
-		}
-		tr.Scheduler = scheduler.NewScheduler(&cfg.Config.Notification, tr.PushDB, taskFunc)
+		tr.Scheduler = scheduler.NewScheduler(&cfg.Config.Notification, tr.PushDB, tr.RunWithResult)
 		if err := tr.Scheduler.Start(ctx); err != nil {
 			log.Printf("Failed to start scheduler: %v", err)
 		}
 	}
 
+	// 3. 处理重试队列
+	if tr.Outbox != nil {
+		tr.Outbox.ReloadConfig(cfg.Config.Notification.Outbox)
+	}
+
 	log.Println("Configuration reloaded successfully")
 	return nil
 }
 
-// RunWithCount 执行任务并返回推送数量
-func (tr *TaskRunner) RunWithCount() (int, error) {
-	logOutput, err := tr.Run()
-	if err != nil {
-		return 0, err
-	}
-	
-	// 这里简化处理，实际可以从日志中解析数量
-	_ = logOutput
-	return 0, nil
+// RunWithResult 执行任务并返回推送条数和各渠道的发送结果，供定时调度记录推送历史
+func (tr *TaskRunner) RunWithResult() (*scheduler.TaskResult, error) {
+	_, result, err := tr.run()
+	return result, err
 }
 
+// Run 执行任务并返回任务日志
 func (tr *TaskRunner) Run() (string, error) {
+	logOutput, _, err := tr.run()
+	return logOutput, err
+}
+
+func (tr *TaskRunner) run() (string, *scheduler.TaskResult, error) {
 	tr.mu.Lock()
 	if tr.IsRunning {
 		tr.mu.Unlock()
-		return "", fmt.Errorf("task is already running")
+		return "", nil, fmt.Errorf("task is already running")
 	}
 	tr.IsRunning = true
 	tr.mu.Unlock()
@@ -189,6 +372,7 @@ func (tr *TaskRunner) Run() (string, error) {
 
 	logger.Println("Task started...")
 	tr.LastRunTime = time.Now()
+	result := &scheduler.TaskResult{}
 
 	// 1. 加载配置
 	cfg, err := config.LoadConfig(tr.ConfigPath, tr.KeywordPath)
@@ -196,19 +380,27 @@ func (tr *TaskRunner) Run() (string, error) {
 		errMsg := fmt.Sprintf("Failed to load config: %v", err)
 		logger.Println(errMsg)
 		tr.LastLog = logBuf.String()
-		return tr.LastLog, err
+		return tr.LastLog, result, err
 	}
 	logger.Printf("Config loaded. Mode: %s, Platforms: %d, Keywords Groups: %d\n", 
 		cfg.Config.Report.Mode, len(cfg.Config.Platforms), len(cfg.KeywordGroups))
 
-	// 2. 初始化模块
-	f := filter.NewKeywordFilter(cfg.KeywordGroups, cfg.GlobalFilters)
-	r := rank.NewWeightedRanker(cfg.Config.Weight, cfg.Config.Platforms)
-	n := notifier.NewNotificationManager(cfg.Config)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
+	// 2. 初始化模块
+	f, err := tr.keywordFilterFor(ctx, cfg)
+	if err != nil {
+		logger.Printf("Failed to build filter: %v\n", err)
+		tr.LastLog = logBuf.String()
+		return tr.LastLog, result, err
+	}
+	r, err := tr.newRanker(cfg)
+	if err != nil {
+		logger.Printf("Invalid rank strategy, falling back to %s: %v\n", rank.StrategyWeighted, err)
+	}
+	n := notifier.NewNotificationManager(cfg.Config, cfg.KeywordGroups)
+
 	var rawData map[string][]*model.NewsItem
 
 	// 3. 根据模式获取数据
@@ -220,7 +412,7 @@ func (tr *TaskRunner) Run() (string, error) {
 			errMsg := "Data cache not initialized for daily mode"
 			logger.Println(errMsg)
 			tr.LastLog = logBuf.String()
-			return tr.LastLog, fmt.Errorf(errMsg)
+			return tr.LastLog, result, fmt.Errorf(errMsg)
 		}
 
 		cachedItems := tr.DataCache.GetDailyCache()
@@ -239,7 +431,7 @@ func (tr *TaskRunner) Run() (string, error) {
 			errMsg := "Data cache not initialized for incremental mode"
 			logger.Println(errMsg)
 			tr.LastLog = logBuf.String()
-			return tr.LastLog, fmt.Errorf(errMsg)
+			return tr.LastLog, result, fmt.Errorf(errMsg)
 		}
 
 		c := crawler.NewNewsNowCrawler(cfg.Config)
@@ -248,12 +440,15 @@ func (tr *TaskRunner) Run() (string, error) {
 			errMsg := fmt.Sprintf("Crawler failed: %v", err)
 			logger.Println(errMsg)
 			tr.LastLog = logBuf.String()
-			return tr.LastLog, err
+			return tr.LastLog, result, err
 		}
 		logger.Printf("Crawled data from %d platforms", len(data))
 
-		// 保存原始爬取数据到历史记录
+		// 回填首次发现时间，并保存原始爬取数据到历史记录
 		if tr.DataCache != nil {
+			if err := tr.DataCache.TrackSeen(data, time.Now()); err != nil {
+				logger.Printf("Warning: Failed to track seen items: %v", err)
+			}
 			if err := tr.DataCache.SaveCrawlHistory(data); err != nil {
 				logger.Printf("Warning: Failed to save crawl history: %v", err)
 			}
@@ -283,12 +478,15 @@ func (tr *TaskRunner) Run() (string, error) {
 			errMsg := fmt.Sprintf("Crawler failed: %v", err)
 			logger.Println(errMsg)
 			tr.LastLog = logBuf.String()
-			return tr.LastLog, err
+			return tr.LastLog, result, err
 		}
 		logger.Printf("Crawled data from %d platforms", len(data))
 
-		// 保存原始爬取数据到历史记录
+		// 回填首次发现时间，并保存原始爬取数据到历史记录
 		if tr.DataCache != nil {
+			if err := tr.DataCache.TrackSeen(data, time.Now()); err != nil {
+				logger.Printf("Warning: Failed to track seen items: %v", err)
+			}
 			if err := tr.DataCache.SaveCrawlHistory(data); err != nil {
 				logger.Printf("Warning: Failed to save crawl history: %v", err)
 			}
@@ -298,12 +496,17 @@ func (tr *TaskRunner) Run() (string, error) {
 	}
 
 	// 4. 关键词过滤
-	filteredData, err := f.Filter(rawData)
+	filteredData, err := f.FilterContext(ctx, rawData)
 	if err != nil {
 		errMsg := fmt.Sprintf("Filter failed: %v", err)
 		logger.Println(errMsg)
 		tr.LastLog = logBuf.String()
-		return tr.LastLog, err
+		return tr.LastLog, result, err
+	}
+	if m := f.Semantic(); m != nil {
+		if until, err := m.Unavailable(); err != nil {
+			logger.Printf("Warning: Semantic matching paused until %s, matched by keywords only: %v", until.Format("15:04:05"), err)
+		}
 	}
 
 	totalItems := 0
@@ -315,7 +518,7 @@ func (tr *TaskRunner) Run() (string, error) {
 	if totalItems == 0 {
 		logger.Println("No matching items found, skipping notification")
 		tr.LastLog = logBuf.String()
-		return tr.LastLog, nil
+		return tr.LastLog, result, nil
 	}
 
 	// 5. 排序
@@ -323,9 +526,39 @@ func (tr *TaskRunner) Run() (string, error) {
 
 	// 6. 推送通知
 	if cfg.Config.Notification.EnableNotification {
+		// 去掉被静音的关键词组的新闻
+		rankedItems, muted, err := tr.dropMuted(rankedItems)
+		if err != nil {
+			logger.Printf("Warning: Failed to load muted keyword groups: %v", err)
+		} else if muted > 0 {
+			logger.Printf("Skipped %d items from muted keyword groups", muted)
+		}
+		if len(rankedItems) == 0 {
+			logger.Println("All items are muted, skipping notification")
+			tr.LastLog = logBuf.String()
+			return tr.LastLog, result, nil
+		}
+
 		logger.Printf("Sending notifications for %d items...", len(rankedItems))
-		n.SendAll(ctx, rankedItems)
-		logger.Println("Notification sent")
+		pushItems, err := tr.trackLinks(cfg.Config, rankedItems)
+		if err != nil {
+			logger.Printf("Warning: Failed to build feedback links: %v", err)
+		}
+		if link, err := tr.muteLink(cfg.Config); err != nil {
+			logger.Printf("Warning: Failed to build mute links: %v", err)
+		} else if link != nil {
+			n.SetMuteLink(link)
+		}
+		result.ItemCount = len(pushItems)
+		result.Deliveries = n.SendAll(ctx, pushItems)
+		for _, d := range result.Deliveries {
+			if d.Status == model.DeliverySuccess {
+				logger.Printf("Notification sent via %s (%d messages, %dms)", d.Channel, d.Batches, d.Duration)
+			} else {
+				logger.Printf("Failed to send notification via %s: %s", d.Channel, d.Error)
+			}
+		}
+		tr.enqueueFailed(logger, cfg.Config.Notification.Outbox, result.Deliveries, pushItems)
 
 		// 7. 增量模式下标记已推送
 		if cfg.Config.Report.Mode == "incremental" && tr.DataCache != nil {
@@ -344,9 +577,34 @@ func (tr *TaskRunner) Run() (string, error) {
 		logger.Println("Notification disabled")
 	}
 
+	// 清理超出学习范围的阅读反馈
+	if tr.PushDB != nil {
+		if deleted, err := tr.PushDB.DeleteOldFeedback(feedback.Days(cfg.Config.Feedback)); err == nil && deleted > 0 {
+			logger.Printf("Cleaned %d expired feedback records", deleted)
+		}
+	}
+
 	logger.Println("Task completed.")
 	tr.LastLog = logBuf.String()
-	return tr.LastLog, nil
+	return tr.LastLog, result, nil
+}
+
+// enqueueFailed 开启重试队列时，把发送失败的渠道加入队列，稍后按退避间隔重试
+func (tr *TaskRunner) enqueueFailed(logger *log.Logger, cfg config.OutboxConfig, deliveries []model.Delivery, items []*model.NewsItem) {
+	if !cfg.Enabled || tr.PushDB == nil {
+		return
+	}
+	for _, d := range deliveries {
+		if d.Status == model.DeliverySuccess {
+			continue
+		}
+		entry := outbox.NewEntry(cfg, d, items)
+		if err := tr.PushDB.EnqueueOutbox(entry); err != nil {
+			logger.Printf("Warning: Failed to queue retry for %s: %v", d.Channel, err)
+			continue
+		}
+		logger.Printf("Queued retry for %s at %s", d.Channel, entry.NextAttempt.Format("15:04:05"))
+	}
 }
 
 // FilterAndRankData 对原始数据进行过滤和排序
@@ -358,11 +616,19 @@ func (tr *TaskRunner) FilterAndRankData(rawData map[string][]*model.NewsItem) ([
 	}
 
 	// 初始化过滤器和排序器
-	f := filter.NewKeywordFilter(cfg.KeywordGroups, cfg.GlobalFilters)
-	r := rank.NewWeightedRanker(cfg.Config.Weight, cfg.Config.Platforms)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	f, err := tr.keywordFilterFor(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	r, err := tr.newRanker(cfg)
+	if err != nil {
+		log.Printf("Invalid rank strategy, falling back to %s: %v", rank.StrategyWeighted, err)
+	}
 
 	// 过滤数据
-	filteredData, err := f.Filter(rawData)
+	filteredData, err := f.FilterContext(ctx, rawData)
 	if err != nil {
 		return nil, fmt.Errorf("filter failed: %w", err)
 	}
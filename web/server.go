@@ -4,14 +4,18 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io/fs"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/gotoailab/trendhub/config"
+	"github.com/gotoailab/trendhub/internal/feedback"
 	"github.com/gotoailab/trendhub/internal/logger"
 	"github.com/gotoailab/trendhub/internal/model"
+	"github.com/gotoailab/trendhub/internal/outbox"
+	"github.com/gotoailab/trendhub/internal/pushdb"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,10 +36,17 @@ func (s *Server) Run(addr string) error {
 	http.HandleFunc("/api/run", s.enableCors(s.handleRun))
 	http.HandleFunc("/api/push-records", s.enableCors(s.handlePushRecords))
 	http.HandleFunc("/api/push-records/clear-today", s.enableCors(s.handleClearTodayRecords))
+	http.HandleFunc("/api/outbox", s.enableCors(s.handleOutbox))
 	http.HandleFunc("/api/crawl-history", s.enableCors(s.handleCrawlHistory))
 	http.HandleFunc("/api/crawl-history/recent", s.enableCors(s.handleRecentHistory))
 	http.HandleFunc("/api/version", s.enableCors(s.handleVersion))
 	http.HandleFunc("/api/logs", s.enableCors(s.handleLogs))
+	http.HandleFunc("/api/feedback", s.enableCors(s.handleFeedback))
+	http.HandleFunc(feedback.ClickPath, s.handleFeedbackClick)
+	http.HandleFunc(feedback.MutePath, s.handleFeedbackMute)
+	http.HandleFunc("/api/mutes", s.enableCors(s.handleMutes))
+	http.HandleFunc("/api/feedback/suggestions", s.enableCors(s.handleFeedbackSuggestions))
+	http.HandleFunc("/api/feedback/apply", s.enableCors(s.handleFeedbackApply))
 
 	// 静态文件服务 - 使用嵌入的文件系统
 	staticFS, err := fs.Sub(staticFiles, "static")
@@ -155,6 +166,8 @@ func (s *Server) handleKeywords(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// 关键词已变化，下次任务时重新构建匹配自动机
+		s.Runner.ResetKeywordFilter()
 		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	} else {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -425,3 +438,341 @@ func (s *Server) handleClearTodayRecords(w http.ResponseWriter, r *http.Request)
 		"message": fmt.Sprintf("已清除今天的 %d 条推送记录", deleted),
 	})
 }
+
+// OutboxRequest 操作重试队列中的消息的请求
+type OutboxRequest struct {
+	ID     string `json:"id"`
+	Action string `json:"action"` // replay：把失败队列中的消息移回重试队列并立即重试；delete：删除消息
+}
+
+// handleOutbox 获取重试队列和失败队列（GET），或重新发送、删除其中的消息（POST）
+func (s *Server) handleOutbox(w http.ResponseWriter, r *http.Request) {
+	if s.Runner.PushDB == nil {
+		http.Error(w, "Push database not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		pending, err := s.Runner.PushDB.GetOutbox()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dead, err := s.Runner.PushDB.GetDeadLetters()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pending": pending,
+			"dead":    dead,
+		})
+	case "POST":
+		var req OutboxRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		switch req.Action {
+		case "replay":
+			var cfg config.OutboxConfig
+			if global, err := config.LoadConfig(s.Runner.ConfigPath, s.Runner.KeywordPath); err == nil {
+				cfg = global.Config.Notification.Outbox
+			}
+			if err := s.Runner.PushDB.ReplayDeadLetter(req.ID, outbox.Expire(cfg)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Infof("Replaying dead letter %s", req.ID)
+			if s.Runner.Outbox != nil {
+				go s.Runner.Outbox.RetryDue()
+			}
+		case "delete":
+			if err := s.Runner.PushDB.DeleteOutbox(req.ID); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := s.Runner.PushDB.DeleteDeadLetter(req.ID); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		default:
+			http.Error(w, "action must be replay or delete", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// FeedbackRequest Web 界面提交的反馈
+type FeedbackRequest struct {
+	Vote int             `json:"vote"` // 1 有用，-1 没用
+	Item *model.NewsItem `json:"item"`
+}
+
+// handleFeedback 记录读者对新闻的反馈（POST），或获取最近的反馈（GET）
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	if s.Runner.PushDB == nil {
+		http.Error(w, "Push database not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		limit := 50
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			fmt.Sscanf(limitStr, "%d", &limit)
+		}
+		records, err := s.Runner.PushDB.GetRecentFeedback(limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"records": records,
+		})
+	case "POST":
+		var req FeedbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Item == nil || req.Item.Title == "" || (req.Vote != 1 && req.Vote != -1) {
+			http.Error(w, "vote must be 1 or -1 and item.title is required", http.StatusBadRequest)
+			return
+		}
+
+		fb := &pushdb.Feedback{
+			Vote:         req.Vote,
+			Source:       pushdb.FeedbackSourceWeb,
+			Title:        req.Item.Title,
+			URL:          req.Item.URL,
+			SourceID:     req.Item.SourceID,
+			KeywordGroup: req.Item.KeywordGroupKey,
+			Score:        req.Item.Score,
+		}
+		if err := s.Runner.PushDB.SaveFeedback(fb); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+			"id":     fb.ID,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFeedbackClick 推送消息中的跟踪链接：记录点击后跳转到原文
+func (s *Server) handleFeedbackClick(w http.ResponseWriter, r *http.Request) {
+	if s.Runner.PushDB == nil {
+		http.Error(w, "Push database not initialized", http.StatusInternalServerError)
+		return
+	}
+	secret, err := s.Runner.PushDB.FeedbackSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 只用于校验签名，publicURL 不影响结果
+	fb, target, err := feedback.NewLinker("", secret).Parse(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 聊天软件收到推送后会请求链接生成预览，只记录读者在浏览器中的点击
+	if r.Method == "GET" && !feedback.IsLinkPreview(r.UserAgent()) {
+		if err := s.Runner.PushDB.SaveFeedback(fb); err != nil {
+			logger.Errorf("Failed to save link feedback: %v", err)
+		}
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleFeedbackMute 推送消息中的静音按钮：静音关键词组后显示结果页面
+func (s *Server) handleFeedbackMute(w http.ResponseWriter, r *http.Request) {
+	if s.Runner.PushDB == nil {
+		http.Error(w, "Push database not initialized", http.StatusInternalServerError)
+		return
+	}
+	secret, err := s.Runner.PushDB.FeedbackSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	group, hours, err := feedback.NewLinker("", secret).ParseMute(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 与跟踪链接一样，聊天软件生成预览或预取链接时不静音
+	if r.Method != "GET" || feedback.IsLinkPreview(r.UserAgent()) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html><html><head><meta charset="utf-8"><title>静音关键词组</title></head>
+<body><p>在浏览器中打开此链接以静音关键词组「%s」。</p></body></html>`, html.EscapeString(group))
+		return
+	}
+	until := time.Now().Add(time.Duration(hours) * time.Hour)
+	if err := s.Runner.PushDB.MuteGroup(group, until); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Infof("Muted keyword group %q until %s", group, until.Format("2006-01-02 15:04"))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>已静音</title></head>
+<body style="font-family: sans-serif; padding: 2em;"><p>已静音关键词组「%s」，%s 之前不再推送该组的新闻。</p><p>可以在 TrendHub 的“阅读反馈”设置中取消静音。</p></body></html>`,
+		html.EscapeString(group), until.Format("01-02 15:04"))
+}
+
+// MuteRequest 静音或取消静音关键词组的请求
+type MuteRequest struct {
+	Group string `json:"group"`
+	Hours int    `json:"hours"` // 静音的小时数，0 表示取消静音
+}
+
+// handleMutes 获取静音中的关键词组（GET），或静音、取消静音关键词组（POST）
+func (s *Server) handleMutes(w http.ResponseWriter, r *http.Request) {
+	if s.Runner.PushDB == nil {
+		http.Error(w, "Push database not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		mutes, err := s.Runner.PushDB.GetMutes()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"mutes": mutes,
+		})
+	case "POST":
+		var req MuteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Group == "" || req.Hours < 0 {
+			http.Error(w, "group is required and hours must not be negative", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if req.Hours == 0 {
+			err = s.Runner.PushDB.UnmuteGroup(req.Group)
+		} else {
+			err = s.Runner.PushDB.MuteGroup(req.Group, time.Now().Add(time.Duration(req.Hours)*time.Hour))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// loadFeedbackSuggestion 读取当前配置并根据反馈生成调整建议
+func (s *Server) loadFeedbackSuggestion() (*config.Config, *feedback.Suggestion, error) {
+	content, err := os.ReadFile(s.Runner.ConfigPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cfg config.Config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, nil, err
+	}
+	suggestion, err := s.Runner.FeedbackSuggestion(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cfg, suggestion, nil
+}
+
+// handleFeedbackSuggestions 根据反馈给出平台权重和 weight 配置的调整建议，不修改配置
+func (s *Server) handleFeedbackSuggestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, suggestion, err := s.loadFeedbackSuggestion()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(suggestion)
+}
+
+// handleFeedbackApply 将调整建议写入配置文件并重新加载
+func (s *Server) handleFeedbackApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg, suggestion, err := s.loadFeedbackSuggestion()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !suggestion.Changed {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "unchanged",
+			"message": "上次应用建议之后的反馈不足或没有需要调整的权重",
+		})
+		return
+	}
+
+	feedback.Apply(cfg, suggestion)
+	yamlBytes, err := yaml.Marshal(cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	path := s.Runner.ConfigPath
+	content, err := os.ReadFile(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 备份失败时不覆盖配置文件，避免应用建议后无法恢复
+	if err := os.WriteFile(path+".bak", content, 0644); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to back up config: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(path, yamlBytes, 0644); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 记录已学习的反馈，下次只从新的反馈中学习，避免重复应用时权重持续漂移
+	if err := s.Runner.PushDB.SetFeedbackAppliedAt(suggestion.Latest); err != nil {
+		logger.Errorf("Failed to save feedback watermark: %v", err)
+	}
+	if err := s.Runner.ReloadConfig(r.Context()); err != nil {
+		logger.Errorf("Warning: Failed to reload configuration: %v", err)
+	}
+
+	logger.Infof("Applied feedback weight suggestions (%d votes)", suggestion.Votes)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ok",
+		"suggestion": suggestion,
+	})
+}